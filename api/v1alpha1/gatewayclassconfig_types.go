@@ -0,0 +1,75 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ServerscomGatewayClassConfigKind is kind of GatewayClass parameters resource
+const ServerscomGatewayClassConfigKind = "ServerscomGatewayClassConfig"
+
+// UpstreamMode defines how load balancer reaches backend Services
+// +kubebuilder:validation:Enum=NodePort;Pod
+type UpstreamMode string
+
+const (
+	// UpstreamModeNodePort sends traffic to Service NodePort of nodes
+	UpstreamModeNodePort UpstreamMode = "NodePort"
+	// UpstreamModePod sends traffic directly to pod IPs from EndpointSlices, pod network must be routable from LB
+	UpstreamModePod UpstreamMode = "Pod"
+)
+
+// ServerscomGatewayClassConfigSpec defines load balancer settings of gateways of the class.
+// Unset fields are left to controller defaults.
+type ServerscomGatewayClassConfigSpec struct {
+	// LocationID is location of load balancers
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	LocationID *int64 `json:"locationID,omitempty"`
+
+	// ClusterID is id of dedicated load balancer cluster, shared cluster is used if not set
+	// +optional
+	// +kubebuilder:validation:MinLength=1
+	ClusterID *string `json:"clusterID,omitempty"`
+
+	// StoreLogs enables storing of load balancer logs
+	// +optional
+	StoreLogs *bool `json:"storeLogs,omitempty"`
+
+	// StoreLogsRegionID is region of stored logs, requires StoreLogs
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	StoreLogsRegionID *int `json:"storeLogsRegionID,omitempty"`
+
+	// Geoip enables GeoIP headers
+	// +optional
+	Geoip *bool `json:"geoip,omitempty"`
+
+	// UpstreamMode is default upstream mode of backends, NodePort if not set.
+	// It can be overridden per Service by annotation.
+	// +optional
+	UpstreamMode *UpstreamMode `json:"upstreamMode,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// ServerscomGatewayClassConfig is referenced by GatewayClass parametersRef.
+type ServerscomGatewayClassConfig struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ServerscomGatewayClassConfigSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ServerscomGatewayClassConfigList contains a list of ServerscomGatewayClassConfig
+type ServerscomGatewayClassConfigList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ServerscomGatewayClassConfig `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ServerscomGatewayClassConfig{}, &ServerscomGatewayClassConfigList{})
+}
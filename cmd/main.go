@@ -1,26 +1,33 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
 	"github.com/serverscom/api-gateway-controller/internal/config"
 	"github.com/serverscom/api-gateway-controller/internal/flags"
 	"github.com/serverscom/api-gateway-controller/internal/gateway/controller"
 	lbsrv "github.com/serverscom/api-gateway-controller/internal/service/lb"
 	tlssrv "github.com/serverscom/api-gateway-controller/internal/service/tls"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	ctrlZap "sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	"k8s.io/apimachinery/pkg/runtime"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 var (
@@ -33,6 +40,8 @@ var (
 func init() {
 	_ = clientgoscheme.AddToScheme(scheme)
 	_ = gatewayv1.Install(scheme)
+	_ = gatewayv1beta1.Install(scheme)
+	_ = v1alpha1.AddToScheme(scheme)
 }
 
 func main() {
@@ -85,18 +94,87 @@ func main() {
 		os.Exit(1)
 	}
 
+	// resolve cluster identity, cache is not started yet so use api reader
+	clusterID := ctrlConf.ClusterID
+	if clusterID == "" {
+		var ns corev1.Namespace
+		if err := mgr.GetAPIReader().Get(context.Background(), client.ObjectKey{Name: metav1.NamespaceSystem}, &ns); err != nil {
+			setupLog.Error(err, "unable to get cluster id from kube-system namespace")
+			os.Exit(1)
+		}
+		clusterID = string(ns.UID)
+	}
+	setupLog.Info("using cluster id", "clusterID", clusterID)
+
+	nodeSelector, err := labels.Parse(ctrlConf.NodeLabelSelector)
+	if err != nil {
+		setupLog.Error(err, "unable to parse node label selector")
+		os.Exit(1)
+	}
+	nodeAddresses, err := controller.NewNodeAddressPolicy(ctrlConf.NodeAddressTypes, ctrlConf.NodeIPFamilies, ctrlConf.NodeDualStack)
+	if err != nil {
+		setupLog.Error(err, "unable to parse node address policy")
+		os.Exit(1)
+	}
+
+	keys := ctrlConf.Keys()
+	lbMgr := lbsrv.NewManager(scCli, clusterID, keys, ctrlConf.LBReplaceOverlap, ctrlConf.LBReplaceTimeout, ctrlConf.DriftReportOnly)
+	tlsMgr := tlssrv.NewManager(scCli, clusterID, keys)
+
 	// setup gw reconciler
 	if err = (&controller.GatewayReconciler{
 		Client:           mgr.GetClient(),
 		Recorder:         mgr.GetEventRecorderFor("gateway-controller"),
 		ControllerName:   ctrlConf.ControllerName,
 		GatewayClassName: ctrlConf.GatewayClassName,
-		LBMgr:            lbsrv.NewManager(scCli),
-		TLSMgr:           tlssrv.NewManager(scCli),
+		LBMgr:            lbMgr,
+		TLSMgr:           tlsMgr,
+
+		DriftCheckInterval: ctrlConf.DriftCheckInterval,
+		DriftReportOnly:    ctrlConf.DriftReportOnly,
+		ClusterID:          clusterID,
+		Keys:               keys,
+		NodeSelector:       nodeSelector,
+
+		CertReleaseInterval:    ctrlConf.CertReleaseInterval,
+		CertReleaseGracePeriod: ctrlConf.CertReleaseGracePeriod,
+		NodeDrainGracePeriod:   ctrlConf.NodeDrainGracePeriod,
+		NodeAddresses:          nodeAddresses,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Gateway")
 		os.Exit(1)
 	}
+	if ctrlConf.NodeDrainGracePeriod > 0 {
+		if err = (&controller.NodeDrainReconciler{
+			Client:       mgr.GetClient(),
+			NodeSelector: nodeSelector,
+			Keys:         keys,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "NodeDrain")
+			os.Exit(1)
+		}
+	}
+
+	// setup orphans sweeper, it requires all gateways and secrets to be visible
+	if ctrlConf.OrphanSweepInterval > 0 {
+		if ctrlConf.Namespace != "" {
+			setupLog.Info("orphans sweeper disabled, it requires watching all namespaces")
+		} else if err = (&controller.OrphanSweeper{
+			Client:         mgr.GetClient(),
+			Recorder:       mgr.GetEventRecorderFor("gateway-controller"),
+			LBMgr:          lbMgr,
+			TLSMgr:         tlsMgr,
+			Interval:       ctrlConf.OrphanSweepInterval,
+			GracePeriod:    ctrlConf.OrphanGracePeriod,
+			DryRun:         ctrlConf.OrphanSweepDryRun,
+			EventNamespace: config.FetchEnv("POD_NAMESPACE", "default"),
+			ClusterID:      clusterID,
+			Keys:           keys,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create orphans sweeper")
+			os.Exit(1)
+		}
+	}
 
 	// Health checks
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -7,9 +7,30 @@ const (
 	GW_FINALIZER            = GW_DOMAIN + "/gateway-cleanup"
 	GW_LABEL_ID             = GW_DOMAIN + "/api-gateway-id"
 	SECRET_LABEL_ID         = GW_DOMAIN + "/api-secret-id"
+	CLUSTER_LABEL_ID        = GW_DOMAIN + "/kube-cluster-id"
+	LB_CONFIG_HASH_LABEL    = GW_DOMAIN + "/config-hash"
+	LB_CERT_LABEL_PREFIX    = GW_DOMAIN + "/cert-"
 	TLS_EXTERNAL_ID_KEY     = "sc-certmgr-cert-id"
+	LB_ID_ANNOTATION        = GW_DOMAIN + "/load-balancer-id"
+	RECLAIM_POLICY_KEY      = GW_DOMAIN + "/reclaim-policy"
+	ALLOW_DELETION_KEY      = GW_DOMAIN + "/allow-deletion"
+	RETAINED_LABEL_ID       = GW_DOMAIN + "/retained-gateway-id"
+	LB_REPLACES_LABEL       = GW_DOMAIN + "/replaces-lb-id"
+	LB_REPLACE_FAILED_LABEL = GW_DOMAIN + "/replace-failed"
+
+	LOCATION_ID_KEY          = GW_DOMAIN + "/location-id"
+	LB_CLUSTER_ID_KEY        = GW_DOMAIN + "/lb-cluster-id"
+	STORE_LOGS_KEY           = GW_DOMAIN + "/store-logs"
+	STORE_LOGS_REGION_ID_KEY = GW_DOMAIN + "/store-logs-region-id"
+	GEOIP_KEY                = GW_DOMAIN + "/geoip"
+	UPSTREAM_MODE_KEY        = GW_DOMAIN + "/upstream-mode"
+
+	NODE_EXCLUDE_LB_LABEL         = "node.kubernetes.io/exclude-from-external-load-balancers"
+	NODE_TO_BE_DELETED_TAINT      = "ToBeDeletedByClusterAutoscaler"
+	NODE_DRAIN_STARTED_ANNOTATION = GW_DOMAIN + "/drain-started-at"
 
 	SC_API_URL = "https://api.servers.com/v1"
 
 	LB_ACTIVE_STATUS = "active"
+	LB_L7_TYPE       = "l7"
 )
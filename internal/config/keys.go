@@ -0,0 +1,102 @@
+package config
+
+import "strings"
+
+// Keys contains label keys and finalizer which mark resources managed by controller instance.
+// Instances with different keys can share one account without touching each other's resources.
+type Keys struct {
+	// GatewayLabel is a LB label key with Gateway UID as value
+	GatewayLabel string
+	// SecretLabel is a certificate label key with Secret UID as value
+	SecretLabel string
+	// Finalizer is added to managed Gateways
+	Finalizer string
+
+	// Old* keys were used before, resources marked with them are migrated to current keys.
+	// Empty value disables migration.
+	OldGatewayLabel string
+	OldSecretLabel  string
+	OldFinalizer    string
+}
+
+// DefaultKeys returns keys used if nothing configured
+func DefaultKeys() Keys {
+	return Keys{
+		GatewayLabel: GW_LABEL_ID,
+		SecretLabel:  SECRET_LABEL_ID,
+		Finalizer:    GW_FINALIZER,
+	}
+}
+
+// Old returns keys to migrate from, they are empty if migration is disabled
+func (k Keys) Old() Keys {
+	return Keys{
+		GatewayLabel: k.OldGatewayLabel,
+		SecretLabel:  k.OldSecretLabel,
+		Finalizer:    k.OldFinalizer,
+	}
+}
+
+// ClusterLabel is a label key with cluster identity, it is stamped on load balancers and certificates
+func (k Keys) ClusterLabel() string {
+	return k.derive(CLUSTER_LABEL_ID)
+}
+
+// RetainedLabel is a LB label key with UID of Gateway which released LB on deletion
+func (k Keys) RetainedLabel() string {
+	return k.derive(RETAINED_LABEL_ID)
+}
+
+// ConfigHashLabel is a LB label key with hash of LB config
+func (k Keys) ConfigHashLabel() string {
+	return k.derive(LB_CONFIG_HASH_LABEL)
+}
+
+// ReplacesLabel is a LB label key with ID of LB being replaced
+func (k Keys) ReplacesLabel() string {
+	return k.derive(LB_REPLACES_LABEL)
+}
+
+// ReplaceFailedLabel is a LB label key with location and cluster of replacement which didn't become active in time
+func (k Keys) ReplaceFailedLabel() string {
+	return k.derive(LB_REPLACE_FAILED_LABEL)
+}
+
+// CertLabelPrefix is a prefix of LB label keys with IDs of certificates used by LB vhosts
+func (k Keys) CertLabelPrefix() string {
+	return k.derive(LB_CERT_LABEL_PREFIX)
+}
+
+// DrainStartedAnnotation is a Node annotation key with time node started draining
+func (k Keys) DrainStartedAnnotation() string {
+	return k.derive(NODE_DRAIN_STARTED_ANNOTATION)
+}
+
+// derive returns key of this instance for default key.
+// Default gateway label keeps default keys, otherwise key is named after gateway label,
+// e.g. staging.example.com/gateway-id gets staging.example.com/gateway-id-config-hash.
+func (k Keys) derive(defaultKey string) string {
+	if k.GatewayLabel == "" || k.GatewayLabel == GW_LABEL_ID {
+		return defaultKey
+	}
+	return k.GatewayLabel + "-" + strings.TrimPrefix(defaultKey, GW_DOMAIN+"/")
+}
+
+// Reserved returns true if label key is managed by controller and can't be set by users:
+// it is in controller domain, or it is one of current or old keys or derived from them.
+func (k Keys) Reserved(key string) bool {
+	if strings.HasPrefix(key, GW_DOMAIN+"/") {
+		return true
+	}
+	for _, keys := range []Keys{k, k.Old()} {
+		if key == keys.GatewayLabel || key == keys.SecretLabel {
+			return key != ""
+		}
+		// derived keys of custom gateway label, including certificate labels, are named after it,
+		// derived keys of default one are in controller domain
+		if keys.GatewayLabel != "" && strings.HasPrefix(key, keys.GatewayLabel+"-") {
+			return true
+		}
+	}
+	return false
+}
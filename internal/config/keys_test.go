@@ -0,0 +1,61 @@
+package config
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestKeysDerived(t *testing.T) {
+	g := NewWithT(t)
+
+	keys := DefaultKeys()
+	g.Expect(keys.ClusterLabel()).To(Equal(CLUSTER_LABEL_ID))
+	g.Expect(keys.RetainedLabel()).To(Equal(RETAINED_LABEL_ID))
+	g.Expect(keys.ConfigHashLabel()).To(Equal(LB_CONFIG_HASH_LABEL))
+	g.Expect(keys.ReplacesLabel()).To(Equal(LB_REPLACES_LABEL))
+	g.Expect(keys.ReplaceFailedLabel()).To(Equal(LB_REPLACE_FAILED_LABEL))
+	g.Expect(keys.CertLabelPrefix()).To(Equal(LB_CERT_LABEL_PREFIX))
+	g.Expect(keys.DrainStartedAnnotation()).To(Equal(NODE_DRAIN_STARTED_ANNOTATION))
+
+	keys = Keys{GatewayLabel: "staging.example.com/gateway-id", OldGatewayLabel: GW_LABEL_ID}
+	g.Expect(keys.ClusterLabel()).To(Equal("staging.example.com/gateway-id-kube-cluster-id"))
+	g.Expect(keys.RetainedLabel()).To(Equal("staging.example.com/gateway-id-retained-gateway-id"))
+	g.Expect(keys.ConfigHashLabel()).To(Equal("staging.example.com/gateway-id-config-hash"))
+	g.Expect(keys.ReplacesLabel()).To(Equal("staging.example.com/gateway-id-replaces-lb-id"))
+	g.Expect(keys.ReplaceFailedLabel()).To(Equal("staging.example.com/gateway-id-replace-failed"))
+	g.Expect(keys.CertLabelPrefix()).To(Equal("staging.example.com/gateway-id-cert-"))
+	g.Expect(keys.DrainStartedAnnotation()).To(Equal("staging.example.com/gateway-id-drain-started-at"))
+	g.Expect(keys.Old().ClusterLabel()).To(Equal(CLUSTER_LABEL_ID))
+}
+
+func TestKeysReserved(t *testing.T) {
+	g := NewWithT(t)
+
+	keys := Keys{
+		GatewayLabel:    "staging.example.com/gateway-id",
+		SecretLabel:     "staging.example.com/secret-id",
+		OldGatewayLabel: "legacy/gw",
+		OldSecretLabel:  "legacy/secret",
+	}
+	for _, key := range []string{
+		GW_LABEL_ID,
+		GW_DOMAIN + "/anything",
+		keys.GatewayLabel,
+		keys.SecretLabel,
+		keys.ClusterLabel(),
+		keys.ConfigHashLabel(),
+		keys.ReplacesLabel(),
+		keys.CertLabelPrefix() + "cert-id",
+		keys.OldGatewayLabel,
+		keys.OldSecretLabel,
+		keys.Old().ClusterLabel(),
+		keys.Old().ReplacesLabel(),
+		keys.Old().CertLabelPrefix() + "cert-id",
+	} {
+		g.Expect(keys.Reserved(key)).To(BeTrue(), key)
+	}
+	for _, key := range []string{"team", "staging.example.com/team", "legacy/owner"} {
+		g.Expect(keys.Reserved(key)).To(BeFalse(), key)
+	}
+}
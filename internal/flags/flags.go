@@ -3,6 +3,7 @@ package flags
 import (
 	"flag"
 	"os"
+	"time"
 
 	"github.com/serverscom/api-gateway-controller/internal/config"
 
@@ -22,6 +23,32 @@ type Configuration struct {
 	GatewayClassName string
 	ControllerName   string
 	LBLabelSelector  string
+	SecretLabelKey   string
+	FinalizerName    string
+	ClusterID        string
+
+	OldLBLabelSelector string
+	OldSecretLabelKey  string
+	OldFinalizerName   string
+
+	DriftCheckInterval time.Duration
+	DriftReportOnly    bool
+
+	OrphanSweepInterval time.Duration
+	OrphanGracePeriod   time.Duration
+	OrphanSweepDryRun   bool
+
+	CertReleaseInterval    time.Duration
+	CertReleaseGracePeriod time.Duration
+
+	LBReplaceOverlap time.Duration
+	LBReplaceTimeout time.Duration
+
+	NodeLabelSelector    string
+	NodeDrainGracePeriod time.Duration
+	NodeAddressTypes     []string
+	NodeIPFamilies       []string
+	NodeDualStack        bool
 }
 
 func ParseFlags() (*Configuration, error) {
@@ -45,7 +72,47 @@ func ParseFlags() (*Configuration, error) {
 		controllerName = flags.String("controller-name", config.DEFAULT_CONTROLLER_NAME,
 			`Controller field to match in GatewayClass resources.`)
 		lbLabelSelector = flags.String("lb-label-selector", config.GW_LABEL_ID,
-			`Label selector key for Services representing API Gateways.`)
+			`Label key marking load balancers managed by this controller instance, other provider label keys are named after it.`)
+		secretLabelKey = flags.String("secret-label-key", config.SECRET_LABEL_ID,
+			`Label key marking certificates managed by this controller instance.`)
+		finalizerName = flags.String("finalizer-name", config.GW_FINALIZER,
+			`Finalizer added to Gateways managed by this controller instance.`)
+		oldLBLabelSelector = flags.String("old-lb-label-selector", "",
+			`Previously used load balancers label key, such load balancers are relabelled. (Optional)`)
+		oldSecretLabelKey = flags.String("old-secret-label-key", "",
+			`Previously used certificates label key, such certificates are relabelled. (Optional)`)
+		oldFinalizerName = flags.String("old-finalizer-name", "",
+			`Previously used Gateway finalizer, it is replaced by current one. (Optional)`)
+		clusterID = flags.String("cluster-id", "",
+			`Identity of this cluster stamped on provider resources. (Optional, default = kube-system namespace UID)`)
+		driftCheckInterval = flags.Duration("drift-check-interval", 10*time.Minute,
+			`Interval of comparing managed load balancers with desired state. (0 = disabled)`)
+		driftReportOnly = flags.Bool("drift-report-only", false,
+			`Only report load balancers drift without repairing it, changes of Gateway config are still applied.`)
+		orphanSweepInterval = flags.Duration("orphan-sweep-interval", 0,
+			`Interval of deleting load balancers and certificates which Gateway or Secret no longer exists. (0 = disabled)`)
+		orphanGracePeriod = flags.Duration("orphan-grace-period", time.Hour,
+			`Time resource should stay orphaned before deletion.`)
+		orphanSweepDryRun = flags.Bool("orphan-sweep-dry-run", false,
+			`Only report orphaned resources without deleting them.`)
+		certReleaseInterval = flags.Duration("cert-release-interval", 5*time.Minute,
+			`Interval of deleting certificates which Secrets are no longer referenced by any Gateway. (0 = disabled)`)
+		certReleaseGracePeriod = flags.Duration("cert-release-grace-period", 10*time.Minute,
+			`Time certificate should stay unreferenced before deletion.`)
+		lbReplaceOverlap = flags.Duration("lb-replace-overlap", 10*time.Minute,
+			`Time both old and new load balancers are published in Gateway status when load balancer is replaced.`)
+		lbReplaceTimeout = flags.Duration("lb-replace-timeout", 30*time.Minute,
+			`Time replacement load balancer should become active in, otherwise replacement is cancelled. (0 = no timeout)`)
+		nodeLabelSelector = flags.String("node-label-selector", "",
+			`Label selector of nodes used as load balancer upstreams. (Optional, empty = all eligible nodes)`)
+		nodeDrainGracePeriod = flags.Duration("node-drain-grace-period", 2*time.Minute,
+			`Time cordoned or deleted node gets no new traffic before removal from upstreams. (0 = remove at once)`)
+		nodeAddressTypes = flags.StringSlice("node-address-types", []string{"ExternalIP", "InternalIP"},
+			`Preference order of node address types used as upstreams.`)
+		nodeIPFamilies = flags.StringSlice("node-ip-families", []string{"IPv4", "IPv6"},
+			`Preference order of IP families of node and pod addresses used as upstreams.`)
+		nodeDualStack = flags.Bool("node-dual-stack", false,
+			`Use node and pod addresses of every IP family as upstreams instead of the most preferred one.`)
 	)
 
 	flags.AddGoFlagSet(flag.CommandLine)
@@ -66,7 +133,45 @@ func ParseFlags() (*Configuration, error) {
 		GatewayClassName: *gatewayClassName,
 		ControllerName:   *controllerName,
 		LBLabelSelector:  *lbLabelSelector,
+		SecretLabelKey:   *secretLabelKey,
+		FinalizerName:    *finalizerName,
+		ClusterID:        *clusterID,
+
+		OldLBLabelSelector: *oldLBLabelSelector,
+		OldSecretLabelKey:  *oldSecretLabelKey,
+		OldFinalizerName:   *oldFinalizerName,
+
+		DriftCheckInterval: *driftCheckInterval,
+		DriftReportOnly:    *driftReportOnly,
+
+		OrphanSweepInterval: *orphanSweepInterval,
+		OrphanGracePeriod:   *orphanGracePeriod,
+		OrphanSweepDryRun:   *orphanSweepDryRun,
+
+		CertReleaseInterval:    *certReleaseInterval,
+		CertReleaseGracePeriod: *certReleaseGracePeriod,
+
+		LBReplaceOverlap: *lbReplaceOverlap,
+		LBReplaceTimeout: *lbReplaceTimeout,
+
+		NodeLabelSelector:    *nodeLabelSelector,
+		NodeDrainGracePeriod: *nodeDrainGracePeriod,
+		NodeAddressTypes:     *nodeAddressTypes,
+		NodeIPFamilies:       *nodeIPFamilies,
+		NodeDualStack:        *nodeDualStack,
 	}
 
 	return conf, nil
 }
+
+// Keys returns label keys and finalizer configured for controller instance
+func (c *Configuration) Keys() config.Keys {
+	return config.Keys{
+		GatewayLabel:    c.LBLabelSelector,
+		SecretLabel:     c.SecretLabelKey,
+		Finalizer:       c.FinalizerName,
+		OldGatewayLabel: c.OldLBLabelSelector,
+		OldSecretLabel:  c.OldSecretLabelKey,
+		OldFinalizer:    c.OldFinalizerName,
+	}
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	lbsrv "github.com/serverscom/api-gateway-controller/internal/service/lb"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
+
+	serverscom "github.com/serverscom/serverscom-go-client/pkg"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// releaseCertificates deletes managed certificates which Secrets are not referenced by any managed Gateway
+// and which are not used by vhosts of any managed load balancer, including ones labelled with keys to migrate from.
+// Certificate is deleted only after it stays unreferenced during CertReleaseGracePeriod,
+// so certificate created for a Gateway which LB isn't updated yet survives.
+// Release runs at most once per CertReleaseInterval since it lists all LBs and certificates.
+func (r *GatewayReconciler) releaseCertificates(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx)
+
+	r.certMu.Lock()
+	defer r.certMu.Unlock()
+	if r.now == nil {
+		r.now = time.Now
+	}
+	if r.certUnused == nil {
+		r.certUnused = make(map[string]time.Time)
+	}
+	now := r.now()
+	if r.CertReleaseInterval <= 0 || now.Sub(r.lastCertRelease) < r.CertReleaseInterval {
+		return nil
+	}
+	r.lastCertRelease = now
+
+	referenced, err := r.referencedSecretUIDs(ctx)
+	if err != nil {
+		return err
+	}
+	lbs, err := r.LBMgr.ListManagedLBs(ctx)
+	if err != nil {
+		return err
+	}
+	certs, err := r.TLSMgr.ListManagedCertificates(ctx)
+	if err != nil {
+		return err
+	}
+	unused, settled := unusedCertificates(certs, lbs, r.Keys, r.ClusterID)
+	if !settled {
+		log.V(1).Info("Skip certificates release, some load balancers are not active yet")
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	for _, cert := range unused {
+		if referenced[cert.Labels[r.Keys.SecretLabel]] {
+			continue
+		}
+		seen[cert.ID] = true
+		first, ok := r.certUnused[cert.ID]
+		if !ok {
+			r.certUnused[cert.ID] = now
+			log.V(1).Info("Unreferenced certificate found", "certificate", cert.Name, "id", cert.ID, "gracePeriod", r.CertReleaseGracePeriod)
+			continue
+		}
+		if now.Sub(first) < r.CertReleaseGracePeriod {
+			continue
+		}
+		if err := r.TLSMgr.DeleteCertificate(ctx, cert.ID); err != nil {
+			return err
+		}
+		log.Info("Unused certificate deleted", "certificate", cert.Name, "id", cert.ID)
+		delete(r.certUnused, cert.ID)
+	}
+
+	// forget certificates which are referenced again
+	for id := range r.certUnused {
+		if !seen[id] {
+			delete(r.certUnused, id)
+		}
+	}
+	return nil
+}
+
+// referencedSecretUIDs returns UIDs of Secrets referenced by TLS listeners of managed Gateways.
+// Missing Secrets are skipped, certificate of such Secret is released by orphans sweeper.
+func (r *GatewayReconciler) referencedSecretUIDs(ctx context.Context) (map[string]bool, error) {
+	var gateways gatewayv1.GatewayList
+	if err := r.List(ctx, &gateways); err != nil {
+		return nil, fmt.Errorf("failed to list Gateways: %w", err)
+	}
+	uids := make(map[string]bool)
+	for _, gw := range gateways.Items {
+		managed, err := r.isManagedGateway(ctx, &gw)
+		if err != nil {
+			return nil, err
+		}
+		if !managed {
+			continue
+		}
+		for _, listener := range gw.Spec.Listeners {
+			if listener.TLS == nil {
+				continue
+			}
+			for _, ref := range listener.TLS.CertificateRefs {
+				if (ref.Kind != nil && *ref.Kind != kindSecret) || (ref.Group != nil && *ref.Group != "") {
+					continue
+				}
+				ns := gw.Namespace
+				if ref.Namespace != nil && *ref.Namespace != "" {
+					ns = string(*ref.Namespace)
+				}
+				var secret corev1.Secret
+				if err := r.Get(ctx, client.ObjectKey{Namespace: ns, Name: string(ref.Name)}, &secret); err != nil {
+					if apierrors.IsNotFound(err) {
+						continue
+					}
+					return nil, err
+				}
+				uids[string(secret.UID)] = true
+			}
+		}
+	}
+	return uids, nil
+}
+
+// unusedCertificates returns certificates of the cluster which are not used by any of load balancers.
+// Certificates without cluster label are never returned, they may belong to another cluster.
+// settled is false if some load balancer is not active, its vhosts may still use
+// certificates already removed from its labels.
+func unusedCertificates(certs []serverscom.SSLCertificate, lbs []serverscom.LoadBalancer, keys config.Keys, clusterID string) (unused []serverscom.SSLCertificate, settled bool) {
+	for _, lb := range lbs {
+		if !strings.EqualFold(lb.Status, config.LB_ACTIVE_STATUS) {
+			return nil, false
+		}
+	}
+	inUse := lbsrv.CertificatesInUse(lbs, keys)
+	for _, cert := range certs {
+		if !inUse[cert.ID] && utils.OwnedByCluster(cert.Labels, keys, clusterID) {
+			unused = append(unused, cert)
+		}
+	}
+	return unused, true
+}
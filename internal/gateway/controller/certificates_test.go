@@ -0,0 +1,174 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/mocks"
+
+	. "github.com/onsi/gomega"
+	serverscom "github.com/serverscom/serverscom-go-client/pkg"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_unusedCertificates(t *testing.T) {
+	g := NewWithT(t)
+
+	certs := []serverscom.SSLCertificate{{ID: "c1"}, {ID: "c2"}, {ID: "c3"}}
+	lbs := []serverscom.LoadBalancer{
+		{ID: "lb1", Status: config.LB_ACTIVE_STATUS, Labels: map[string]string{config.LB_CERT_LABEL_PREFIX + "c1": "true"}},
+		{ID: "lb2", Status: "Active", Labels: map[string]string{config.LB_CERT_LABEL_PREFIX + "c3": "true"}},
+	}
+
+	unused, settled := unusedCertificates(certs, lbs, config.DefaultKeys(), "")
+	g.Expect(settled).To(BeTrue())
+	g.Expect(unused).To(Equal([]serverscom.SSLCertificate{{ID: "c2"}}))
+
+	// certificates of other clusters and without cluster label are never deleted
+	certs = []serverscom.SSLCertificate{
+		{ID: "own", Labels: map[string]string{config.CLUSTER_LABEL_ID: "a"}},
+		{ID: "foreign", Labels: map[string]string{config.CLUSTER_LABEL_ID: "b"}},
+		{ID: "legacy"},
+	}
+	unused, settled = unusedCertificates(certs, lbs, config.DefaultKeys(), "a")
+	g.Expect(settled).To(BeTrue())
+	g.Expect(unused).To(HaveLen(1))
+	g.Expect(unused[0].ID).To(Equal("own"))
+
+	// certificate used by lb labelled with old keys during keys migration is in use
+	keys := config.Keys{GatewayLabel: "staging/gw-id", SecretLabel: "staging/secret-id", OldGatewayLabel: config.GW_LABEL_ID}
+	certs = []serverscom.SSLCertificate{{ID: "c1"}, {ID: "c2"}}
+	unused, settled = unusedCertificates(certs, lbs[:1], keys, "")
+	g.Expect(settled).To(BeTrue())
+	g.Expect(unused).To(Equal([]serverscom.SSLCertificate{{ID: "c2"}}))
+
+	lbs[1].Status = "pending"
+	unused, settled = unusedCertificates(certs, lbs, config.DefaultKeys(), "")
+	g.Expect(settled).To(BeFalse())
+	g.Expect(unused).To(BeEmpty())
+}
+
+func TestReleaseCertificates(t *testing.T) {
+	scheme := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: config.DEFAULT_GATEWAY_CLASS},
+		Spec:       gatewayv1.GatewayClassSpec{ControllerName: gatewayv1.GatewayController(config.DEFAULT_CONTROLLER_NAME)},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: testGw, Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: gatewayv1.ObjectName(config.DEFAULT_GATEWAY_CLASS),
+			Listeners: []gatewayv1.Listener{{
+				Name:     "https",
+				Port:     443,
+				Protocol: gatewayv1.HTTPSProtocolType,
+				TLS: &gatewayv1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "new"}},
+				},
+			}},
+		},
+	}
+	secrets := []client.Object{
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: testGwNs, UID: "secret-new"}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: testGwNs, UID: "secret-old"}},
+	}
+	// new certificate is created for Gateway which LB isn't updated yet
+	certs := []serverscom.SSLCertificate{
+		{ID: "new", Labels: map[string]string{config.SECRET_LABEL_ID: "secret-new"}},
+		{ID: "old", Labels: map[string]string{config.SECRET_LABEL_ID: "secret-old"}},
+	}
+
+	tests := []struct {
+		name       string
+		lbs        []serverscom.LoadBalancer
+		setupMocks func(tlsMgr *mocks.MockTLSManagerInterface)
+		wantErr    bool
+	}{
+		{
+			name: "delete unreferenced certificate after grace period",
+			lbs: []serverscom.LoadBalancer{
+				{ID: "lb1", Status: config.LB_ACTIVE_STATUS},
+			},
+			setupMocks: func(tlsMgr *mocks.MockTLSManagerInterface) {
+				tlsMgr.EXPECT().DeleteCertificate(gomock.Any(), "old").Return(nil)
+			},
+		},
+		{
+			name: "lb update in progress",
+			lbs: []serverscom.LoadBalancer{
+				{ID: "lb1", Status: "pending"},
+			},
+			setupMocks: func(tlsMgr *mocks.MockTLSManagerInterface) {},
+		},
+		{
+			name: "delete failed",
+			lbs: []serverscom.LoadBalancer{
+				{ID: "lb1", Status: config.LB_ACTIVE_STATUS},
+			},
+			setupMocks: func(tlsMgr *mocks.MockTLSManagerInterface) {
+				tlsMgr.EXPECT().DeleteCertificate(gomock.Any(), "old").Return(errors.New("api error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			lbMgr := mocks.NewMockLBManagerInterface(mockCtrl)
+			tlsMgr := mocks.NewMockTLSManagerInterface(mockCtrl)
+			// second call is throttled
+			lbMgr.EXPECT().ListManagedLBs(gomock.Any()).Return(tt.lbs, nil).Times(2)
+			tlsMgr.EXPECT().ListManagedCertificates(gomock.Any()).Return(certs, nil).Times(2)
+			tt.setupMocks(tlsMgr)
+
+			now := time.Now()
+			objs := append([]client.Object{gc.DeepCopy(), gw.DeepCopy()}, secrets...)
+			r := &GatewayReconciler{
+				Client:                 fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build(),
+				ControllerName:         config.DEFAULT_CONTROLLER_NAME,
+				LBMgr:                  lbMgr,
+				TLSMgr:                 tlsMgr,
+				Keys:                   config.DefaultKeys(),
+				CertReleaseInterval:    time.Minute,
+				CertReleaseGracePeriod: 10 * time.Minute,
+				now:                    func() time.Time { return now },
+			}
+			// first seen unreferenced
+			g.Expect(r.releaseCertificates(context.Background())).To(Succeed())
+
+			now = now.Add(30 * time.Second)
+			g.Expect(r.releaseCertificates(context.Background())).To(Succeed())
+
+			now = now.Add(time.Hour)
+			err := r.releaseCertificates(context.Background())
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+			} else {
+				g.Expect(err).To(BeNil())
+			}
+		})
+	}
+}
+
+func TestReleaseCertificates_Disabled(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	r := &GatewayReconciler{
+		LBMgr:  mocks.NewMockLBManagerInterface(mockCtrl),
+		TLSMgr: mocks.NewMockTLSManagerInterface(mockCtrl),
+	}
+	g.Expect(r.releaseCertificates(context.Background())).To(Succeed())
+}
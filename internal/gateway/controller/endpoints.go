@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// upstreamMode returns upstream mode of Service, annotation of Service overrides mode of gateway class
+func upstreamMode(svc *corev1.Service, classMode v1alpha1.UpstreamMode) (v1alpha1.UpstreamMode, error) {
+	mode := classMode
+	if v, ok := svc.Annotations[config.UPSTREAM_MODE_KEY]; ok {
+		mode = v1alpha1.UpstreamMode(v)
+	}
+	switch mode {
+	case "", v1alpha1.UpstreamModeNodePort:
+		return v1alpha1.UpstreamModeNodePort, nil
+	case v1alpha1.UpstreamModePod:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown upstream mode %q, expected %s or %s", mode, v1alpha1.UpstreamModeNodePort, v1alpha1.UpstreamModePod)
+}
+
+// listEndpointSlices returns EndpointSlices of service
+func (r *GatewayReconciler) listEndpointSlices(ctx context.Context, svc *corev1.Service) ([]discoveryv1.EndpointSlice, error) {
+	var endpointSlices discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &endpointSlices,
+		client.InNamespace(svc.Namespace),
+		client.MatchingLabels{discoveryv1.LabelServiceName: svc.Name},
+	); err != nil {
+		return nil, err
+	}
+	return endpointSlices.Items, nil
+}
+
+// isEndpointReady returns true if endpoint can receive traffic, unknown readiness is interpreted as ready
+func isEndpointReady(ep discoveryv1.Endpoint) bool {
+	return ep.Conditions.Ready == nil || *ep.Conditions.Ready
+}
+
+// slicesOfFamilies returns EndpointSlices with address type of given IP families
+func slicesOfFamilies(endpointSlices []discoveryv1.EndpointSlice, families []corev1.IPFamily) []discoveryv1.EndpointSlice {
+	var result []discoveryv1.EndpointSlice
+	for _, slice := range endpointSlices {
+		if slices.Contains(families, corev1.IPFamily(slice.AddressType)) {
+			result = append(result, slice)
+		}
+	}
+	return result
+}
+
+// hasFamily returns function checking if there are EndpointSlices of IP family
+func hasFamily(endpointSlices []discoveryv1.EndpointSlice) func(corev1.IPFamily) bool {
+	return func(family corev1.IPFamily) bool {
+		return slices.ContainsFunc(endpointSlices, func(s discoveryv1.EndpointSlice) bool {
+			return corev1.IPFamily(s.AddressType) == family && len(s.Endpoints) > 0
+		})
+	}
+}
+
+// getPodEndpoints returns ready pod IP and target port pairs of service port sorted by IP.
+// Target port is taken from EndpointSlice port with the same name as service port.
+// IP families of pods are selected by node address policy, dual stack pods get upstream per family.
+func (r *GatewayReconciler) getPodEndpoints(ctx context.Context, svc *corev1.Service, port *corev1.ServicePort) ([]types.PodEndpoint, error) {
+	endpointSlices, err := r.listEndpointSlices(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	families := r.NodeAddresses.selectFamilies(hasFamily(endpointSlices))
+
+	var result []types.PodEndpoint
+	for _, slice := range slicesOfFamilies(endpointSlices, families) {
+		var targetPort int32
+		for _, p := range slice.Ports {
+			name := ""
+			if p.Name != nil {
+				name = *p.Name
+			}
+			if name == port.Name && p.Port != nil {
+				targetPort = *p.Port
+				break
+			}
+		}
+		if targetPort == 0 {
+			continue
+		}
+		for _, ep := range slice.Endpoints {
+			if !isEndpointReady(ep) || len(ep.Addresses) == 0 {
+				continue
+			}
+			result = append(result, types.PodEndpoint{IP: ep.Addresses[0], Port: targetPort})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].IP != result[j].IP {
+			return result[i].IP < result[j].IP
+		}
+		return result[i].Port < result[j].Port
+	})
+	return result, nil
+}
+
+// getLocalEndpoints returns number of ready endpoints of service by node IP.
+// Only nodes from nodeIPs (node name to IPs) are counted, endpoints on other nodes can't get traffic from LB.
+// Service without ready endpoints on such nodes can't serve traffic anyway,
+// nil is returned for it, so all nodes are kept as upstreams and LB config stays valid.
+func (r *GatewayReconciler) getLocalEndpoints(ctx context.Context, svc *corev1.Service, nodeIPs map[string][]string) (map[string]int32, error) {
+	endpointSlices, err := r.listEndpointSlices(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	// dual stack service has slices per family, pods of one family are counted
+	single := NodeAddressPolicy{Families: r.NodeAddresses.Families}
+	families := single.selectFamilies(hasFamily(endpointSlices))
+
+	result := make(map[string]int32)
+	for _, slice := range slicesOfFamilies(endpointSlices, families) {
+		for _, ep := range slice.Endpoints {
+			if ep.NodeName == nil || !isEndpointReady(ep) {
+				continue
+			}
+			for _, ip := range nodeIPs[*ep.NodeName] {
+				result[ip]++
+			}
+		}
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newEndpointSlice(name, svcName string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: testGwNs,
+			Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints:   endpoints,
+	}
+}
+
+func newEndpoint(node string, ready bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		Addresses:  []string{"10.244.0.1"},
+		NodeName:   &node,
+		Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+	}
+}
+
+func Test_getLocalEndpoints(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: testGwNs}}
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		newEndpointSlice("svc-a", "svc", newEndpoint("n1", true), newEndpoint("n1", true), newEndpoint("n2", false)),
+		newEndpointSlice("svc-b", "svc", newEndpoint("n2", true), newEndpoint("n3", true)),
+		newEndpointSlice("other-a", "other", newEndpoint("n1", true)),
+		newEndpointSlice("idle-a", "idle", newEndpoint("n1", false)),
+	).Build()
+	r := &GatewayReconciler{Client: c}
+	nodeIPs := map[string][]string{"n1": {"10.0.0.1"}, "n2": {"10.0.0.2"}}
+
+	endpoints, err := r.getLocalEndpoints(context.Background(), svc, nodeIPs)
+	g.Expect(err).To(BeNil())
+	g.Expect(endpoints).To(Equal(map[string]int32{"10.0.0.1": 2, "10.0.0.2": 1}))
+
+	// no ready endpoints, all nodes are kept
+	svc.Name = "idle"
+	endpoints, err = r.getLocalEndpoints(context.Background(), svc, nodeIPs)
+	g.Expect(err).To(BeNil())
+	g.Expect(endpoints).To(BeNil())
+}
+
+func Test_upstreamMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		classMode  v1alpha1.UpstreamMode
+		expected   v1alpha1.UpstreamMode
+		wantErr    bool
+	}{
+		{name: "default", expected: v1alpha1.UpstreamModeNodePort},
+		{name: "from class", classMode: v1alpha1.UpstreamModePod, expected: v1alpha1.UpstreamModePod},
+		{name: "service overrides class", annotation: "NodePort", classMode: v1alpha1.UpstreamModePod, expected: v1alpha1.UpstreamModeNodePort},
+		{name: "unknown", annotation: "pod", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}
+			if tt.annotation != "" {
+				svc.Annotations = map[string]string{config.UPSTREAM_MODE_KEY: tt.annotation}
+			}
+			mode, err := upstreamMode(svc, tt.classMode)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(BeNil())
+			g.Expect(mode).To(Equal(tt.expected))
+		})
+	}
+}
+
+func Test_getPodEndpoints(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+
+	httpName, metricsName := "http", "metrics"
+	httpPort, metricsPort, otherHTTPPort := int32(8080), int32(9090), int32(8081)
+	a := newEndpointSlice("svc-a", "svc", newEndpoint("n1", true), newEndpoint("n2", false))
+	a.Endpoints[0].Addresses = []string{"10.244.0.2"}
+	a.Ports = []discoveryv1.EndpointPort{{Name: &httpName, Port: &httpPort}, {Name: &metricsName, Port: &metricsPort}}
+	// pods of the same service may use different target ports
+	b := newEndpointSlice("svc-b", "svc", newEndpoint("n2", true))
+	b.Ports = []discoveryv1.EndpointPort{{Name: &httpName, Port: &otherHTTPPort}}
+	v6 := newEndpointSlice("svc-c", "svc", newEndpoint("n1", true))
+	v6.AddressType = discoveryv1.AddressTypeIPv6
+	v6.Endpoints[0].Addresses = []string{"fd00::1"}
+	v6.Ports = b.Ports
+
+	r := &GatewayReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(a, b, v6).Build()}
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: testGwNs}}
+
+	endpoints, err := r.getPodEndpoints(context.Background(), svc, &corev1.ServicePort{Name: "http", Port: 80})
+	g.Expect(err).To(BeNil())
+	g.Expect(endpoints).To(Equal([]types.PodEndpoint{{IP: "10.244.0.1", Port: 8081}, {IP: "10.244.0.2", Port: 8080}}))
+
+	endpoints, err = r.getPodEndpoints(context.Background(), svc, &corev1.ServicePort{Name: "admin", Port: 81})
+	g.Expect(err).To(BeNil())
+	g.Expect(endpoints).To(BeEmpty())
+}
+
+func Test_getPodEndpoints_DualStack(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+
+	name, port := "http", int32(8080)
+	v4 := newEndpointSlice("svc-v4", "svc", newEndpoint("n1", true))
+	v4.Ports = []discoveryv1.EndpointPort{{Name: &name, Port: &port}}
+	v6 := newEndpointSlice("svc-v6", "svc", newEndpoint("n1", true))
+	v6.AddressType = discoveryv1.AddressTypeIPv6
+	v6.Endpoints[0].Addresses = []string{"fd00::1"}
+	v6.Ports = v4.Ports
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(v4, v6).Build()
+	svc := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: testGwNs}}
+	svcPort := &corev1.ServicePort{Name: "http", Port: 80}
+
+	r := &GatewayReconciler{Client: c, NodeAddresses: NodeAddressPolicy{DualStack: true}}
+	endpoints, err := r.getPodEndpoints(context.Background(), svc, svcPort)
+	g.Expect(err).To(BeNil())
+	g.Expect(endpoints).To(Equal([]types.PodEndpoint{{IP: "10.244.0.1", Port: 8080}, {IP: "fd00::1", Port: 8080}}))
+
+	r.NodeAddresses = NodeAddressPolicy{Families: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol}}
+	endpoints, err = r.getPodEndpoints(context.Background(), svc, svcPort)
+	g.Expect(err).To(BeNil())
+	g.Expect(endpoints).To(Equal([]types.PodEndpoint{{IP: "fd00::1", Port: 8080}}))
+
+	// pods of dual stack service are counted once for every node address
+	local, err := r.getLocalEndpoints(context.Background(), svc, map[string][]string{"n1": {"10.0.0.1", "fd00::10"}})
+	g.Expect(err).To(BeNil())
+	g.Expect(local).To(Equal(map[string]int32{"10.0.0.1": 1, "fd00::10": 1}))
+}
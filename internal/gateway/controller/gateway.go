@@ -2,17 +2,24 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
 	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/metrics"
 	lbsrv "github.com/serverscom/api-gateway-controller/internal/service/lb"
 	tlssrv "github.com/serverscom/api-gateway-controller/internal/service/tls"
 	"github.com/serverscom/api-gateway-controller/internal/types"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
@@ -25,10 +32,21 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 var (
-	IPAddressType = gatewayv1.IPAddressType
+	IPAddressType       = gatewayv1.IPAddressType
+	HostnameAddressType = gatewayv1.HostnameAddressType
+)
+
+const (
+	// ListenerReasonUnsupportedValue is used when listener config is valid but not supported by controller
+	ListenerReasonUnsupportedValue gatewayv1.ListenerConditionReason = "UnsupportedValue"
+	// RouteReasonNoNodePort is used when backend Service port has no NodePort allocated
+	RouteReasonNoNodePort gatewayv1.RouteConditionReason = "NoNodePort"
+	// RouteReasonInvalidUpstreamMode is used when backend Service has unknown upstream mode
+	RouteReasonInvalidUpstreamMode gatewayv1.RouteConditionReason = "InvalidUpstreamMode"
 )
 
 // GatewayReconciler reconciles a Gateway object
@@ -40,31 +58,114 @@ type GatewayReconciler struct {
 
 	LBMgr  lbsrv.LBManagerInterface
 	TLSMgr tlssrv.TLSManagerInterface
+
+	// DriftCheckInterval is an interval of periodic comparing of LB with desired state, 0 disables it
+	DriftCheckInterval time.Duration
+	// DriftReportOnly marks drift events as report only, LB manager is configured not to repair drift
+	DriftReportOnly bool
+	// ClusterID is identity of cluster stamped on provider resources
+	ClusterID string
+	// Keys contains finalizer and label keys of this controller instance
+	Keys config.Keys
+	// NodeSelector limits nodes used as upstreams, nil selects all eligible nodes
+	NodeSelector labels.Selector
+	// NodeDrainGracePeriod is time cordoned or deleted node gets no new traffic before removal from upstreams
+	NodeDrainGracePeriod time.Duration
+	// NodeAddresses selects node and pod addresses used as upstreams
+	NodeAddresses NodeAddressPolicy
+	// CertReleaseInterval is a minimal interval between releases of unreferenced certificates, 0 disables release
+	CertReleaseInterval time.Duration
+	// CertReleaseGracePeriod is time certificate should stay unreferenced before deletion
+	CertReleaseGracePeriod time.Duration
+
+	// drains contains drains of nodes by node name
+	drains  map[string]*nodeDrain
+	drainMu sync.Mutex
+	now     func() time.Time
+
+	// certUnused contains time when certificate was found unreferenced for the first time by certificate ID
+	certUnused      map[string]time.Time
+	lastCertRelease time.Time
+	certMu          sync.Mutex
+
+	// driftChecked contains time of last drift check by gateway UID
+	driftChecked map[string]time.Time
+	driftMu      sync.Mutex
 }
 
-// SetupWithManager sets up controller with Manager
+// SetupWithManager sets up controller with Manager.
+// Class parameters are watched only if their CRD is installed.
 func (r *GatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	installed, err := classConfigInstalled(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(
 			&gatewayv1.Gateway{},
-			builder.WithPredicates(r.managedPredicate()),
+			builder.WithPredicates(
+				r.managedPredicate(),
+				predicate.Or(predicate.GenerationChangedPredicate{}, annotationChangedPredicate(
+					config.LB_ID_ANNOTATION, config.RECLAIM_POLICY_KEY, config.ALLOW_DELETION_KEY,
+				)),
+			),
 		).
 		Watches(
 			&gatewayv1.HTTPRoute{},
 			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForHTTPRoute),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
 		).
 		Watches(
 			&corev1.Service{},
 			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForService),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
 		).
 		Watches(
 			&corev1.Secret{},
 			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForSecret),
+			builder.WithPredicates(secretDataChangedPredicate()),
+		).
+		Watches(
+			&gatewayv1beta1.ReferenceGrant{},
+			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForReferenceGrant),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		).
+		Watches(
+			&corev1.Node{},
+			nodeEventHandler{EventHandler: handler.EnqueueRequestsFromMapFunc(r.findGatewaysForNode), r: r},
+			builder.WithPredicates(nodeChangedPredicate(r.NodeSelector)),
+		).
+		Watches(
+			&discoveryv1.EndpointSlice{},
+			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForEndpointSlice),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&gatewayv1.GatewayClass{},
+			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForGatewayClass),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+	if installed {
+		b = b.Watches(
+			&v1alpha1.ServerscomGatewayClassConfig{},
+			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForClassParams),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}),
+		)
+	}
+	return b.
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForConfigMap),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+		).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.findGatewaysForNamespace),
+			builder.WithPredicates(namespaceLabelsChangedPredicate()),
 		).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 1,
 		}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
 		Complete(r)
 }
 
@@ -77,8 +178,8 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 	}
 
 	// cleanup if gw was deleted
-	if !gw.DeletionTimestamp.IsZero() && controllerutil.ContainsFinalizer(&gw, config.GW_FINALIZER) {
-		if err := r.cleanup(ctx, &gw, config.GW_FINALIZER); err != nil {
+	if !gw.DeletionTimestamp.IsZero() && r.hasFinalizer(&gw) {
+		if err := r.cleanup(ctx, &gw); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, nil
@@ -91,7 +192,7 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 
 	// cleanup and update Programmed cond if not managed but was before
 	if !managed {
-		if err := r.cleanup(ctx, &gw, config.GW_FINALIZER); err != nil {
+		if err := r.cleanup(ctx, &gw); err != nil {
 			return ctrl.Result{}, err
 		}
 
@@ -111,79 +212,159 @@ func (r *GatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, nil
 	}
 
-	// add finalizer
-	if !controllerutil.ContainsFinalizer(&gw, config.GW_FINALIZER) {
+	// add finalizer, replace old one if configured
+	if !controllerutil.ContainsFinalizer(&gw, r.Keys.Finalizer) || r.hasOldFinalizer(&gw) {
 		orig := gw.DeepCopy()
-		controllerutil.AddFinalizer(&gw, config.GW_FINALIZER)
+		controllerutil.AddFinalizer(&gw, r.Keys.Finalizer)
+		if r.Keys.OldFinalizer != "" {
+			controllerutil.RemoveFinalizer(&gw, r.Keys.OldFinalizer)
+		}
 		if err := r.Patch(ctx, &gw, client.MergeFrom(orig)); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
-	tlsInfo, err := r.buildTLSInfo(ctx, &gw)
+	params, err := r.gatewayParams(ctx, &gw)
 	if err != nil {
-		r.Recorder.Event(&gw, corev1.EventTypeWarning, "InvalidTLS", err.Error())
-		_ = r.setGatewayStatusCondition(ctx, &gw, "Accepted", "InvalidTLS", err.Error(), metav1.ConditionFalse)
+		var paramsErr *InvalidParametersError
+		if !errors.As(err, &paramsErr) {
+			return ctrl.Result{}, err
+		}
+		reason := string(gatewayv1.GatewayReasonInvalidParameters)
+		r.Recorder.Event(&gw, corev1.EventTypeWarning, reason, err.Error())
+		_ = r.setGatewayStatusCondition(ctx, &gw, "Accepted", reason, err.Error(), metav1.ConditionFalse)
 		return ctrl.Result{}, nil
+	}
 
+	tlsInfo, tlsConds, err := r.buildTLSInfo(ctx, &gw)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	gwInfo, err := r.buildGatewayInfo(ctx, &gw)
+	gwInfo, err := r.buildGatewayInfo(ctx, &gw, params, tlsConds)
 	if err != nil {
+		var invalidErr *invalidGatewayError
+		if !errors.As(err, &invalidErr) {
+			return ctrl.Result{}, err
+		}
 		r.Recorder.Event(&gw, corev1.EventTypeWarning, "InvalidGateway", err.Error())
 		_ = r.setGatewayStatusCondition(ctx, &gw, "Accepted", "InvalidGateway", err.Error(), metav1.ConditionFalse)
 		return ctrl.Result{}, nil
 	}
+	gwInfo.Labels = infrastructureLabels(&gw, r.Keys)
+
+	// update routes status
+	if err := r.updateRouteStatuses(ctx, &gw, gwInfo.Routes); err != nil {
+		if apierrors.IsConflict(err) {
+			return ctrl.Result{Requeue: true}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	// reject gateway only if none of listeners can be programmed
+	listenerErrs := listenerErrors(gw.Spec.Listeners, gwInfo.Listeners)
+	if len(listenerErrs) == len(gw.Spec.Listeners) {
+		msg := fmt.Sprintf("No valid listeners:\n%s", joinErrors(listenerErrs))
+		r.Recorder.Event(&gw, corev1.EventTypeWarning, "ListenersNotValid", msg)
+		_ = r.setGatewayStatus(ctx, &gw, gwInfo.Listeners, nil,
+			metav1.Condition{
+				Type:    string(gatewayv1.GatewayConditionAccepted),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(gatewayv1.GatewayReasonListenersNotValid),
+				Message: msg,
+			},
+			metav1.Condition{
+				Type:    string(gatewayv1.GatewayConditionProgrammed),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(gatewayv1.GatewayReasonInvalid),
+				Message: "Gateway has no valid listeners",
+			},
+		)
+		return ctrl.Result{}, nil
+	}
 
 	// set Accepted cond
-	_ = r.setGatewayStatusCondition(ctx, &gw, "Accepted", "Accepted", "Gateway is valid and accepted", metav1.ConditionTrue)
+	if len(listenerErrs) > 0 {
+		msg := fmt.Sprintf("Some listeners are not valid:\n%s", joinErrors(listenerErrs))
+		r.Recorder.Event(&gw, corev1.EventTypeWarning, "ListenersNotValid", msg)
+		_ = r.setGatewayStatusCondition(ctx, &gw, "Accepted", string(gatewayv1.GatewayReasonListenersNotValid), msg, metav1.ConditionTrue)
+	} else {
+		_ = r.setGatewayStatusCondition(ctx, &gw, "Accepted", "Accepted", "Gateway is valid and accepted", metav1.ConditionTrue)
+	}
 
 	// sync tls
 	hostsCertIDMap, err := r.TLSMgr.EnsureTLS(ctx, tlsInfo)
 	if err != nil {
-		_ = r.setGatewayStatusCondition(ctx, &gw, "Programmed", "SyncTLSFailed", err.Error(), metav1.ConditionFalse)
-		r.Recorder.Event(&gw, corev1.EventTypeWarning, "SyncTLSFailed", err.Error())
+		reason := syncFailedReason(err, "SyncTLSFailed")
+		_ = r.setGatewayProgrammed(ctx, &gw, gwInfo.Listeners, reason, err.Error(), metav1.ConditionFalse)
+		r.Recorder.Event(&gw, corev1.EventTypeWarning, reason, err.Error())
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
+	// check lb drift, not more often than DriftCheckInterval since events trigger reconcile much more often
+	if meta.IsStatusConditionTrue(gw.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed)) && r.driftCheckDue(string(gw.UID)) {
+		drift, err := r.LBMgr.DetectDrift(ctx, gwInfo, hostsCertIDMap)
+		if err != nil {
+			ctrl.LoggerFrom(ctx).Error(err, "Failed to detect load balancer drift")
+		} else if len(drift) > 0 {
+			metrics.DriftDetectedTotal.WithLabelValues(gw.Namespace, gw.Name).Inc()
+			msg := fmt.Sprintf("Load balancer differs from desired state: %s", strings.Join(drift, "; "))
+			// in report only mode LB manager doesn't repair drift, desired config changes are still applied
+			if r.DriftReportOnly {
+				msg += " (report only)"
+			} else {
+				msg += ", repairing"
+			}
+			r.Recorder.Event(&gw, corev1.EventTypeWarning, "DriftDetected", msg)
+		}
+	}
+
 	// sync lb
 	lb, err := r.LBMgr.EnsureLB(ctx, gwInfo, hostsCertIDMap)
 	if err != nil {
-		_ = r.setGatewayStatusCondition(ctx, &gw, "Programmed", "SyncFailed", err.Error(), metav1.ConditionFalse)
-		r.Recorder.Event(&gw, corev1.EventTypeWarning, "SyncFailed", err.Error())
+		reason := syncFailedReason(err, "SyncFailed")
+		_ = r.setGatewayProgrammed(ctx, &gw, gwInfo.Listeners, reason, err.Error(), metav1.ConditionFalse)
+		r.Recorder.Event(&gw, corev1.EventTypeWarning, reason, err.Error())
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 
 	}
 
 	if strings.ToLower(lb.Status) != config.LB_ACTIVE_STATUS {
 		msg := "Load balancer created, waiting for status=Active"
-		_ = r.setGatewayStatusCondition(ctx, &gw, "Programmed", "Created", msg, metav1.ConditionFalse)
+		_ = r.setGatewayProgrammed(ctx, &gw, gwInfo.Listeners, "Created", msg, metav1.ConditionFalse)
 		r.Recorder.Event(&gw, corev1.EventTypeWarning, "Created", msg)
 		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 	}
 
-	var addresses []gatewayv1.GatewayStatusAddress
-	for _, ip := range lb.ExternalAddresses {
-		addresses = append(addresses, gatewayv1.GatewayStatusAddress{Type: &IPAddressType, Value: ip})
-	}
+	addresses := statusAddresses(lb.ExternalAddresses)
 
-	// not use SetGatewayStatusCondition because we need update addresses too
-	orig := gw.DeepCopy()
-	gw.Status.Addresses = addresses
+	// not use setGatewayProgrammed because we need update addresses too
 	cond := metav1.Condition{
-		Type:               "Programmed",
-		Status:             metav1.ConditionTrue,
-		Reason:             "Programmed",
-		Message:            "Successfully programmed",
-		ObservedGeneration: gw.Generation,
+		Type:    "Programmed",
+		Status:  metav1.ConditionTrue,
+		Reason:  "Programmed",
+		Message: "Successfully programmed",
 	}
-	meta.SetStatusCondition(&gw.Status.Conditions, cond)
-	if err := r.Status().Patch(ctx, &gw, client.MergeFrom(orig)); err != nil {
+	if err := r.setGatewayStatus(ctx, &gw, gwInfo.Listeners, addresses, cond); err != nil {
 		return ctrl.Result{}, err
 	}
 	r.Recorder.Event(&gw, corev1.EventTypeNormal, "Synced", "Successfully synced")
+	r.reportNodeDrains(&gw, gwInfo)
 
-	return ctrl.Result{}, nil
+	// old lb is deleted by next passes when replacement is active long enough
+	if lbsrv.ReplacementInProgress(lb, r.Keys) {
+		msg := fmt.Sprintf("Load balancer is being replaced, addresses: %s", strings.Join(lb.ExternalAddresses, ", "))
+		r.Recorder.Event(&gw, corev1.EventTypeNormal, "Replacing", msg)
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
+	// lb is updated, certificates detached from it can be deleted, release is throttled by CertReleaseInterval
+	if err := r.releaseCertificates(ctx); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to release unused certificates")
+	}
+
+	// resync periodically to detect out-of-band LB changes, draining nodes are removed when drain ends
+	return ctrl.Result{RequeueAfter: r.drainRequeueAfter(gwInfo.NodeDrains, r.DriftCheckInterval)}, nil
 }
 
 // isManagedGateway checks if gateway has our controller name and class
@@ -206,6 +387,19 @@ func (r *GatewayReconciler) isManagedGateway(ctx context.Context, gw *gatewayv1.
 	return true, nil
 }
 
+// gatewayParams returns LB params from GatewayClass of the gateway overridden by gateway infrastructure
+func (r *GatewayReconciler) gatewayParams(ctx context.Context, gw *gatewayv1.Gateway) (types.LBParams, error) {
+	var gwClass gatewayv1.GatewayClass
+	if err := r.Get(ctx, client.ObjectKey{Name: string(gw.Spec.GatewayClassName)}, &gwClass); err != nil {
+		return types.LBParams{}, err
+	}
+	params, err := resolveClassParams(ctx, r.Client, &gwClass)
+	if err != nil {
+		return types.LBParams{}, err
+	}
+	return resolveGatewayParams(ctx, r.Client, gw, params)
+}
+
 // managedPredicate filters not managed gateways before reconcile loop
 func (r *GatewayReconciler) managedPredicate() predicate.Predicate {
 	return predicate.Funcs{
@@ -228,15 +422,42 @@ func (r *GatewayReconciler) managedPredicate() predicate.Predicate {
 	}
 }
 
-// cleanup ensures that load balancer deleted and removes finalizer
-func (r *GatewayReconciler) cleanup(ctx context.Context, gw *gatewayv1.Gateway, finalizer string) error {
-	labelSelector := config.GW_LABEL_ID + "=" + string(gw.UID)
-	if err := r.LBMgr.DeleteLB(ctx, labelSelector); err != nil {
+// cleanup releases load balancer according to reclaim policy and removes finalizer.
+// Protected load balancer is kept together with finalizer unless deletion is allowed.
+func (r *GatewayReconciler) cleanup(ctx context.Context, gw *gatewayv1.Gateway) error {
+	policy, valid, err := r.reclaimPolicy(ctx, gw)
+	if err != nil {
 		return err
 	}
+	if !valid {
+		msg := fmt.Sprintf("Unknown reclaim policy, treating as %s", policy)
+		r.Recorder.Event(gw, corev1.EventTypeWarning, "InvalidReclaimPolicy", msg)
+	}
+
+	switch {
+	case policy == ReclaimPolicyProtect && !deletionAllowed(gw):
+		msg := fmt.Sprintf("Load balancer is protected from deletion, set annotation %s=true to allow it", config.ALLOW_DELETION_KEY)
+		r.Recorder.Event(gw, corev1.EventTypeWarning, "DeletionProtected", msg)
+		return nil
+	case policy == ReclaimPolicyRetain:
+		if err := r.LBMgr.RetainLB(ctx, string(gw.UID)); err != nil {
+			return err
+		}
+		r.Recorder.Event(gw, corev1.EventTypeNormal, "Retained", "Load balancer retained")
+	default:
+		if err := r.LBMgr.DeleteLB(ctx, string(gw.UID)); err != nil {
+			return err
+		}
+	}
+
+	r.forgetDriftCheck(string(gw.UID))
+	metrics.DriftDetectedTotal.DeleteLabelValues(gw.Namespace, gw.Name)
 
 	orig := gw.DeepCopy()
-	controllerutil.RemoveFinalizer(gw, finalizer)
+	controllerutil.RemoveFinalizer(gw, r.Keys.Finalizer)
+	if r.Keys.OldFinalizer != "" {
+		controllerutil.RemoveFinalizer(gw, r.Keys.OldFinalizer)
+	}
 	if err := r.Patch(ctx, gw, client.MergeFrom(orig)); err != nil {
 		return err
 	}
@@ -244,10 +465,57 @@ func (r *GatewayReconciler) cleanup(ctx context.Context, gw *gatewayv1.Gateway,
 	return nil
 }
 
+// driftCheckDue returns true and records check time if DriftCheckInterval passed since last drift check of the gateway.
+// First check of the gateway is done right away.
+func (r *GatewayReconciler) driftCheckDue(uid string) bool {
+	if r.DriftCheckInterval <= 0 {
+		return false
+	}
+	r.driftMu.Lock()
+	defer r.driftMu.Unlock()
+	if r.driftChecked == nil {
+		r.driftChecked = make(map[string]time.Time)
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	now := r.now()
+	if last, ok := r.driftChecked[uid]; ok && now.Sub(last) < r.DriftCheckInterval {
+		return false
+	}
+	r.driftChecked[uid] = now
+	return true
+}
+
+// forgetDriftCheck drops time of last drift check of the gateway
+func (r *GatewayReconciler) forgetDriftCheck(uid string) {
+	r.driftMu.Lock()
+	defer r.driftMu.Unlock()
+	delete(r.driftChecked, uid)
+}
+
+// hasFinalizer returns true if gateway has current or old finalizer
+func (r *GatewayReconciler) hasFinalizer(gw *gatewayv1.Gateway) bool {
+	return controllerutil.ContainsFinalizer(gw, r.Keys.Finalizer) || r.hasOldFinalizer(gw)
+}
+
+// hasOldFinalizer returns true if gateway has finalizer to migrate from
+func (r *GatewayReconciler) hasOldFinalizer(gw *gatewayv1.Gateway) bool {
+	return r.Keys.OldFinalizer != "" && controllerutil.ContainsFinalizer(gw, r.Keys.OldFinalizer)
+}
+
 // buildGatewayInfo gathers all info needed to build load balancer input.
-func (r *GatewayReconciler) buildGatewayInfo(ctx context.Context, gw *gatewayv1.Gateway) (*types.GatewayInfo, error) {
+// It also collects status of each listener and each HTTPRoute referencing the gateway.
+// params are LB settings of the gateway, tlsConds contains failed conditions of listeners with invalid tls config.
+// Returns invalidGatewayError if gateway can't be programmed, unresolved route references are reported in route statuses.
+func (r *GatewayReconciler) buildGatewayInfo(
+	ctx context.Context,
+	gw *gatewayv1.Gateway,
+	params types.LBParams,
+	tlsConds map[string]metav1.Condition,
+) (*types.GatewayInfo, error) {
 	log := ctrl.LoggerFrom(ctx)
-	nodeIps, err := r.getNodesIpList(ctx)
+	nodes, err := r.getNodesIpList(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get nodes IPs: %w", err)
 	}
@@ -255,10 +523,11 @@ func (r *GatewayReconciler) buildGatewayInfo(ctx context.Context, gw *gatewayv1.
 	// prepare listeners
 	seenListeners := make(map[gatewayv1.SectionName]bool)
 	var listeners []types.ListenerInfo
+	listenerStatuses := make(map[string]*types.ListenerStatusInfo)
 
 	for _, l := range gw.Spec.Listeners {
 		if seenListeners[l.Name] {
-			return nil, fmt.Errorf("duplicate listener name: %q", l.Name)
+			return nil, &invalidGatewayError{Message: fmt.Sprintf("duplicate listener name: %q", l.Name)}
 		}
 		seenListeners[l.Name] = true
 		var hostname string
@@ -286,60 +555,168 @@ func (r *GatewayReconciler) buildGatewayInfo(ctx context.Context, gw *gatewayv1.
 			AllowedFrom: allowedFrom,
 			Selector:    selector,
 		})
+		var tlsCond *metav1.Condition
+		if c, ok := tlsConds[string(l.Name)]; ok {
+			tlsCond = &c
+		}
+		listenerStatuses[string(l.Name)] = buildListenerStatusInfo(l, tlsCond, gw.Generation)
 	}
+	setListenerConflicts(gw.Spec.Listeners, listenerStatuses, gw.Generation)
 
 	vhostMap := map[string]*types.VHostInfo{}
-	routeForDomain := map[string]string{}
+	routeInfos := map[string]*types.RouteInfo{}
 
 	var httpRoutes gatewayv1.HTTPRouteList
 	if err := r.List(ctx, &httpRoutes); err != nil {
 		return nil, fmt.Errorf("failed to list HTTPRoutes: %w", err)
 	}
 
-	for _, route := range httpRoutes.Items {
-		if !isRouteAttachedToGateway(&route, gw) {
-			continue
-		}
-		var routeHostnames []string
-		if len(route.Spec.Hostnames) == 0 {
-			return nil, fmt.Errorf("HTTPRoute %s/%s: Hostname must be specified (no wildcards, no empty values supported)", route.Namespace, route.Name)
-		}
-		for _, h := range route.Spec.Hostnames {
-			host := string(h)
-			if host == "" || strings.ContainsRune(host, '*') {
-				return nil, fmt.Errorf("HTTPRoute %s/%s: Invalid hostname %q (must be concrete, no wildcards, no empty)", route.Namespace, route.Name, host)
+	sortRoutes(httpRoutes.Items)
+
+	for i := range httpRoutes.Items {
+		route := &httpRoutes.Items[i]
+		routeKey := route.Namespace + "/" + route.Name
+		if !isRouteAttachedToGateway(route, gw) {
+			// route doesn't reference gateway anymore, our status entries should be removed
+			if hasRouteParentStatus(route, gw, r.ControllerName) {
+				routeInfos[routeKey] = &types.RouteInfo{Route: route}
 			}
-			routeHostnames = append(routeHostnames, host)
+			continue
 		}
-
-		sectionNames := map[string]struct{}{}
-		for _, pr := range route.Spec.ParentRefs {
-			if pr.SectionName != nil {
-				sectionNames[string(*pr.SectionName)] = struct{}{}
+		routeHostnames, err := validateRouteHostnames(route)
+		if err != nil {
+			// reject only this route, other routes still can be programmed
+			log.Info("HTTPRoute rejected", "http_route", routeKey, "reason", err.Error(), "level", "warn")
+			routeInfos[routeKey] = &types.RouteInfo{
+				Route:   route,
+				Parents: rejectedRouteParents(route, gw, gatewayv1.RouteReasonUnsupportedValue, err.Error()),
 			}
+			continue
 		}
 
 		nsLabels, err := r.getNamespaceLabels(ctx, route.Namespace)
 		if err != nil {
 			return nil, fmt.Errorf("cannot get labels for namespace %q: %w", route.Namespace, err)
 		}
-		for _, hostname := range routeHostnames {
-			if prev, ok := routeForDomain[hostname]; ok && prev != route.Name {
-				return nil, fmt.Errorf("domain %q used in several HTTPRoute: %q and %q", hostname, prev, route.Name)
+
+		// attach route to listeners through each parentRef pointing to the gateway
+		routeInfo := &types.RouteInfo{Route: route}
+		routeInfos[routeKey] = routeInfo
+		attached := map[string]bool{}
+		for _, pr := range route.Spec.ParentRefs {
+			if !parentRefMatchesGateway(pr, route.Namespace, gw) {
+				continue
 			}
-			routeForDomain[hostname] = route.Name
+			matched, reason, msg := attachRouteToListeners(pr, listeners, gw.Namespace, route.Namespace, nsLabels, routeHostnames)
+			status := metav1.ConditionFalse
+			if len(matched) > 0 {
+				status = metav1.ConditionTrue
+			}
+			for _, l := range matched {
+				attached[l.Name] = true
+			}
+			routeInfo.Parents = append(routeInfo.Parents, types.RouteParentInfo{
+				ParentRef: pr,
+				Conditions: []metav1.Condition{{
+					Type:               string(gatewayv1.RouteConditionAccepted),
+					Status:             status,
+					Reason:             string(reason),
+					Message:            msg,
+					ObservedGeneration: route.Generation,
+				}},
+			})
+		}
+		if len(attached) == 0 {
+			continue
+		}
+		for name := range attached {
+			listenerStatuses[name].AttachedRoutes++
+		}
 
-			matchedListeners := []types.ListenerInfo{}
-			for _, l := range listeners {
-				if len(sectionNames) > 0 {
-					if _, ok := sectionNames[l.Name]; !ok {
+		// prepare paths
+		var routePaths []types.PathInfo
+		var refErrs []*refError
+		var invalid []string
+		for _, rule := range route.Spec.Rules {
+			if len(rule.BackendRefs) == 0 {
+				continue
+			}
+			if len(rule.Filters) > 0 {
+				log.Info("HTTPRoute filters will be ignored", "http_route", routeKey, "level", "warn")
+
+			}
+			var backends []types.BackendInfo
+			// weightedErrs contains invalid refs which should receive share of traffic
+			var weightedErrs []*refError
+			for _, ref := range rule.BackendRefs {
+				weight := int32(1)
+				if ref.Weight != nil {
+					weight = *ref.Weight
+				}
+				backend, err := r.resolveBackend(ctx, route, ref.BackendObjectReference, params.UpstreamMode, nodes)
+				if err != nil {
+					var rErr *refError
+					if errors.As(err, &rErr) {
+						refErrs = append(refErrs, rErr)
+						if weight > 0 {
+							weightedErrs = append(weightedErrs, rErr)
+						}
+						continue
+					}
+					return nil, err
+				}
+				backend.Weight = weight
+				backends = addBackend(backends, *backend)
+			}
+			if !hasWeightedBackend(backends) {
+				// no backend can receive traffic, skip rule
+				continue
+			}
+			// LB can't answer part of requests with 500 as Gateway API requires for invalid backends,
+			// their share goes to valid backends, it's reported in route status
+			for _, rErr := range weightedErrs {
+				rErr.Message += ", its traffic share is served by other backends of the rule"
+			}
+			paths := []gatewayv1.HTTPPathMatch{}
+			if len(rule.Matches) == 0 {
+				paths = append(paths, gatewayv1.HTTPPathMatch{Value: ptrString("/")})
+			} else {
+				for _, m := range rule.Matches {
+					if m.Path == nil || m.Path.Value == nil {
 						continue
 					}
+					paths = append(paths, *m.Path)
 				}
-				if !isRouteNamespaceAllowed(l, gw.Namespace, route.Namespace, nsLabels) {
+			}
+			for _, path := range paths {
+				pathType := gatewayv1.PathMatchPathPrefix
+				if path.Type != nil {
+					pathType = *path.Type
+				}
+				if err := validatePathMatch(pathType, *path.Value); err != nil {
+					invalid = append(invalid, err.Error())
 					continue
 				}
-				if hostMatches(l.Hostname, hostname) {
+				routePaths = append(routePaths, types.PathInfo{
+					Path:            *path.Value,
+					PathType:        pathType,
+					Backends:        backends,
+					NodeIps:         nodes.active,
+					DrainingNodeIps: nodes.draining,
+				})
+			}
+		}
+		resolvedRefs := resolvedRefsCondition(refErrs, route.Generation)
+		for i := range routeInfo.Parents {
+			routeInfo.Parents[i].Conditions = append(routeInfo.Parents[i].Conditions, resolvedRefs)
+		}
+
+		// routes are sorted oldest first, so paths of older routes take precedence
+		var conflicts []string
+		for _, hostname := range routeHostnames {
+			matchedListeners := []types.ListenerInfo{}
+			for _, l := range listeners {
+				if attached[l.Name] && isListenerValid(listenerStatuses[l.Name]) && hostMatches(l.Hostname, hostname) {
 					matchedListeners = append(matchedListeners, l)
 				}
 			}
@@ -384,102 +761,167 @@ func (r *GatewayReconciler) buildGatewayInfo(ctx context.Context, gw *gatewayv1.
 					vh.SSL = true
 				}
 			}
-
-			// prepare paths
-			for _, rule := range route.Spec.Rules {
-				if len(rule.BackendRefs) == 0 {
-					continue
-				}
-				if len(rule.Filters) > 0 {
-					log.Info("HTTPRoute filters will be ignored", "http_route", route.Namespace+"/"+route.Name, "level", "warn")
-
-				}
-				backend := rule.BackendRefs[0]
-				if backend.BackendObjectReference.Group != nil && *backend.BackendObjectReference.Group != "" {
-					return nil, fmt.Errorf("non-core backend groups not supported: %v", *backend.BackendObjectReference.Group)
-				}
-				svcName := string(backend.BackendObjectReference.Name)
-				ns := route.Namespace
-				if backend.BackendObjectReference.Namespace != nil {
-					ns = string(*backend.BackendObjectReference.Namespace)
-				}
-				var svc corev1.Service
-				if err := r.Get(ctx, client.ObjectKey{Namespace: ns, Name: svcName}, &svc); err != nil {
-					return nil, fmt.Errorf("failed to get service %s/%s: %w", ns, svcName, err)
-				}
-				var wantPort int32 = 0
-				if backend.BackendObjectReference.Port != nil {
-					wantPort = int32(*backend.BackendObjectReference.Port)
-				} else if len(svc.Spec.Ports) > 0 {
-					wantPort = svc.Spec.Ports[0].Port
-				}
-				var nodePort int32
-				found := false
-				for _, p := range svc.Spec.Ports {
-					if p.Port == wantPort {
-						if p.NodePort == 0 {
-							return nil, fmt.Errorf("service %s has no NodePort (only NodePort/LoadBalancer supported)", svc.Name)
-						}
-						nodePort = p.NodePort
-						found = true
-						break
-					}
-				}
-				if !found {
-					return nil, fmt.Errorf("service %s: port %d not found", svc.Name, wantPort)
-				}
-				paths := []string{}
-				if len(rule.Matches) == 0 {
-					paths = append(paths, "/")
-				} else {
-					for _, m := range rule.Matches {
-						if m.Path == nil || m.Path.Value == nil {
-							continue
-						}
-						pathType := gatewayv1.PathMatchPathPrefix
-						if m.Path.Type != nil {
-							pathType = *m.Path.Type
-						}
-						if pathType != gatewayv1.PathMatchPathPrefix {
-							log.Info("unsupported match type in rule, only PathPrefix is supported — skipping", "type", pathType, "http_route", route.Namespace+"/"+route.Name, "level", "warn")
-							continue
-						}
-						paths = append(paths, *m.Path.Value)
-					}
-				}
-				for _, path := range paths {
-					vh.Paths = append(vh.Paths, types.PathInfo{
-						Path:     path,
-						Service:  &svc,
-						NodePort: int(nodePort),
-						NodeIps:  nodeIps,
-					})
-				}
+			for _, path := range mergeRoutePaths(vh, routePaths) {
+				conflicts = append(conflicts, hostname+path)
+			}
+		}
+		if len(conflicts) > 0 {
+			log.Info("HTTPRoute paths conflict with older routes", "http_route", routeKey, "paths", conflicts, "level", "warn")
+			invalid = append(invalid, fmt.Sprintf("paths are already used by other rules or older routes: %s", strings.Join(conflicts, ", ")))
+		}
+		if len(invalid) > 0 {
+			log.Info("HTTPRoute has ignored path matches", "http_route", routeKey, "errors", invalid, "level", "warn")
+			partiallyInvalid := metav1.Condition{
+				Type:               string(gatewayv1.RouteConditionPartiallyInvalid),
+				Status:             metav1.ConditionTrue,
+				Reason:             string(gatewayv1.RouteReasonUnsupportedValue),
+				Message:            fmt.Sprintf("some path matches are ignored: %s", strings.Join(invalid, "; ")),
+				ObservedGeneration: route.Generation,
+			}
+			for i := range routeInfo.Parents {
+				routeInfo.Parents[i].Conditions = append(routeInfo.Parents[i].Conditions, partiallyInvalid)
 			}
 		}
 	}
 	gwInfo := &types.GatewayInfo{
-		UID:    string(gw.UID),
-		Name:   gw.Name,
-		NS:     gw.Namespace,
-		VHosts: vhostMap,
+		UID:       string(gw.UID),
+		Name:      gw.Name,
+		NS:        gw.Namespace,
+		VHosts:    vhostMap,
+		Routes:    routeInfos,
+		Listeners: listenerStatuses,
+		AdoptLBID: gw.Annotations[config.LB_ID_ANNOTATION],
+		Params:    params,
+
+		NodeDrains:   nodes.drainUntil,
+		DrainedNodes: nodes.drained,
 	}
 	return gwInfo, nil
 }
 
+// resolveBackend builds backend from Service referenced by backendRef.
+// In NodePort upstream mode it finds NodePort of Service and nodes hosting endpoints of Service with Local traffic policy,
+// in Pod upstream mode it finds ready pod endpoints.
+// Returns refError if backend can't be resolved, such backend should be skipped.
+func (r *GatewayReconciler) resolveBackend(
+	ctx context.Context,
+	route *gatewayv1.HTTPRoute,
+	ref gatewayv1.BackendObjectReference,
+	classMode v1alpha1.UpstreamMode,
+	nodes *nodeIPs,
+) (*types.BackendInfo, error) {
+	svc, port, err := r.resolveBackendRef(ctx, route, ref)
+	if err != nil {
+		return nil, err
+	}
+	mode, err := upstreamMode(svc, classMode)
+	if err != nil {
+		return nil, &refError{
+			Reason:  RouteReasonInvalidUpstreamMode,
+			Message: fmt.Sprintf("backend %s/%s: %s", svc.Namespace, svc.Name, err),
+		}
+	}
+	backend := &types.BackendInfo{Service: svc, ServicePort: port.Port}
+
+	if mode == v1alpha1.UpstreamModePod {
+		backend.Direct = true
+		if backend.PodEndpoints, err = r.getPodEndpoints(ctx, svc, port); err != nil {
+			return nil, fmt.Errorf("failed to get endpoints of service %s/%s: %w", svc.Namespace, svc.Name, err)
+		}
+		return backend, nil
+	}
+
+	if port.NodePort == 0 {
+		return nil, &refError{
+			Reason:  RouteReasonNoNodePort,
+			Message: fmt.Sprintf("backend %s/%s: service has no NodePort (only NodePort/LoadBalancer supported)", svc.Namespace, svc.Name),
+		}
+	}
+	backend.NodePort = int(port.NodePort)
+	if svc.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyLocal {
+		if backend.LocalEndpoints, err = r.getLocalEndpoints(ctx, svc, nodes.byName); err != nil {
+			return nil, fmt.Errorf("failed to get endpoints of service %s/%s: %w", svc.Namespace, svc.Name, err)
+		}
+	}
+	return backend, nil
+}
+
+// resolveBackendRef gets Service referenced by backend and finds its port.
+// Returns refError if reference can't be resolved, such backend should be skipped.
+func (r *GatewayReconciler) resolveBackendRef(ctx context.Context, route *gatewayv1.HTTPRoute, ref gatewayv1.BackendObjectReference) (*corev1.Service, *corev1.ServicePort, error) {
+	if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != kindService) {
+		return nil, nil, &refError{
+			Reason:  gatewayv1.RouteReasonInvalidKind,
+			Message: fmt.Sprintf("backend %q: only core Service kind is supported", ref.Name),
+		}
+	}
+	svcName := string(ref.Name)
+	ns := route.Namespace
+	if ref.Namespace != nil {
+		ns = string(*ref.Namespace)
+	}
+	allowed, err := r.isReferenceAllowed(ctx,
+		objectRef{Group: gatewayv1.GroupName, Kind: kindHTTPRoute, Namespace: route.Namespace},
+		backendObjectRef(ns, svcName),
+	)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, &refError{
+			Reason:  gatewayv1.RouteReasonRefNotPermitted,
+			Message: fmt.Sprintf("backend %s/%s: cross-namespace reference is not permitted by any ReferenceGrant", ns, svcName),
+		}
+	}
+	var svc corev1.Service
+	if err := r.Get(ctx, client.ObjectKey{Namespace: ns, Name: svcName}, &svc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil, &refError{
+				Reason:  gatewayv1.RouteReasonBackendNotFound,
+				Message: fmt.Sprintf("backend %s/%s: service not found", ns, svcName),
+			}
+		}
+		return nil, nil, fmt.Errorf("failed to get service %s/%s: %w", ns, svcName, err)
+	}
+	var wantPort int32 = 0
+	if ref.Port != nil {
+		wantPort = int32(*ref.Port)
+	} else if len(svc.Spec.Ports) > 0 {
+		wantPort = svc.Spec.Ports[0].Port
+	}
+	for i := range svc.Spec.Ports {
+		if svc.Spec.Ports[i].Port == wantPort {
+			return &svc, &svc.Spec.Ports[i], nil
+		}
+	}
+	return nil, nil, &refError{
+		Reason:  gatewayv1.RouteReasonBackendNotFound,
+		Message: fmt.Sprintf("backend %s/%s: port %d not found", ns, svcName, wantPort),
+	}
+}
+
 // buildTLSInfo gathers tls info about each domain that can use tls.
-func (r *GatewayReconciler) buildTLSInfo(ctx context.Context, gw *gatewayv1.Gateway) (map[string]types.TLSConfigInfo, error) {
+// Listeners with invalid tls config are skipped and returned with condition describing the problem.
+func (r *GatewayReconciler) buildTLSInfo(ctx context.Context, gw *gatewayv1.Gateway) (map[string]types.TLSConfigInfo, map[string]metav1.Condition, error) {
 	var (
-		result = make(map[string]types.TLSConfigInfo)
-		errs   []error
+		result    = make(map[string]types.TLSConfigInfo)
+		tlsConds  = make(map[string]metav1.Condition)
+		invalidFn = func(l gatewayv1.Listener, condType gatewayv1.ListenerConditionType, reason gatewayv1.ListenerConditionReason, msg string) {
+			tlsConds[string(l.Name)] = metav1.Condition{
+				Type:    string(condType),
+				Status:  metav1.ConditionFalse,
+				Reason:  string(reason),
+				Message: msg,
+			}
+		}
 	)
 
-	for i, listener := range gw.Spec.Listeners {
+	for _, listener := range gw.Spec.Listeners {
 		if listener.Protocol != gatewayv1.HTTPSProtocolType {
 			continue
 		}
 		if err := validateHTTPSListener(listener); err != nil {
-			errs = append(errs, fmt.Errorf("listener[%d]: %w", i, err))
+			invalidFn(listener, gatewayv1.ListenerConditionAccepted, ListenerReasonUnsupportedValue, err.Error())
 			continue
 		}
 		hostname := string(*listener.Hostname)
@@ -495,47 +937,50 @@ func (r *GatewayReconciler) buildTLSInfo(ctx context.Context, gw *gatewayv1.Gate
 		var secretName string
 		var secretNS = gw.Namespace
 		for _, ref := range listener.TLS.CertificateRefs {
-			if (ref.Kind == nil || *ref.Kind == "Secret") && (ref.Group == nil || *ref.Group == "") {
+			if (ref.Kind == nil || *ref.Kind == kindSecret) && (ref.Group == nil || *ref.Group == "") {
 				secretName = string(ref.Name)
+				if ref.Namespace != nil && *ref.Namespace != "" {
+					secretNS = string(*ref.Namespace)
+				}
 				break
 			}
 		}
 		if secretName == "" {
-			errs = append(errs, fmt.Errorf("listener[%d]: no valid refs found", i))
+			invalidFn(listener, gatewayv1.ListenerConditionResolvedRefs, gatewayv1.ListenerReasonInvalidCertificateRef, "no valid certificate refs found")
+			continue
+		}
+		allowed, err := r.isReferenceAllowed(ctx,
+			objectRef{Group: gatewayv1.GroupName, Kind: kindGateway, Namespace: gw.Namespace},
+			secretObjectRef(secretNS, secretName),
+		)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !allowed {
+			invalidFn(listener, gatewayv1.ListenerConditionResolvedRefs, gatewayv1.ListenerReasonRefNotPermitted,
+				fmt.Sprintf("secret %s/%s: cross-namespace reference is not permitted by any ReferenceGrant", secretNS, secretName))
 			continue
 		}
 		var secret corev1.Secret
 		if err := r.Get(ctx, client.ObjectKey{Namespace: secretNS, Name: secretName}, &secret); err != nil {
-			return nil, fmt.Errorf("can't get secret %s/%s: %v", secretNS, secretName, err)
+			if apierrors.IsNotFound(err) {
+				invalidFn(listener, gatewayv1.ListenerConditionResolvedRefs, gatewayv1.ListenerReasonInvalidCertificateRef,
+					fmt.Sprintf("secret %s/%s not found", secretNS, secretName))
+				continue
+			}
+			return nil, nil, fmt.Errorf("can't get secret %s/%s: %v", secretNS, secretName, err)
+		}
+		if len(secret.Data[corev1.TLSCertKey]) == 0 || len(secret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+			invalidFn(listener, gatewayv1.ListenerConditionResolvedRefs, gatewayv1.ListenerReasonInvalidCertificateRef,
+				fmt.Sprintf("secret %s/%s must contain %s and %s", secretNS, secretName, corev1.TLSCertKey, corev1.TLSPrivateKeyKey))
+			continue
 		}
 		result[hostname] = types.TLSConfigInfo{
 			Secret: &secret,
 		}
 	}
 
-	if len(errs) > 0 {
-		return nil, fmt.Errorf("validation errors:\n%s", joinErrors(errs))
-	}
-	return result, nil
-}
-
-// getNodesIpList return node ips
-func (r *GatewayReconciler) getNodesIpList(ctx context.Context) ([]string, error) {
-	var nodes corev1.NodeList
-	if err := r.List(ctx, &nodes); err != nil {
-		return nil, err
-	}
-
-	var nodeIPs []string
-	for _, node := range nodes.Items {
-		for _, addr := range node.Status.Addresses {
-			if addr.Type == corev1.NodeExternalIP || addr.Type == corev1.NodeInternalIP {
-				nodeIPs = append(nodeIPs, addr.Address)
-				break
-			}
-		}
-	}
-	return nodeIPs, nil
+	return result, tlsConds, nil
 }
 
 // getNamespaceLabels return namespace labels
@@ -2,13 +2,18 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -18,6 +23,7 @@ type GatewayClassReconciler struct {
 }
 
 // Reconcile for gateway class ensures that class is managed by our controller and update gateway class status.
+// Class with invalid parametersRef is not accepted.
 func (r *GatewayClassReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := ctrl.LoggerFrom(ctx)
 	var gc gatewayv1.GatewayClass
@@ -30,23 +36,62 @@ func (r *GatewayClassReconciler) Reconcile(ctx context.Context, req ctrl.Request
 	}
 
 	newCondition := metav1.Condition{
-		Type:    string(gatewayv1.GatewayClassConditionStatusAccepted),
-		Status:  metav1.ConditionTrue,
-		Reason:  "Accepted",
-		Message: "GatewayClass accepted by controller",
+		Type:               string(gatewayv1.GatewayClassConditionStatusAccepted),
+		Status:             metav1.ConditionTrue,
+		Reason:             "Accepted",
+		Message:            "GatewayClass accepted by controller",
+		ObservedGeneration: gc.Generation,
+	}
+	if _, err := resolveClassParams(ctx, r.Client, &gc); err != nil {
+		var paramsErr *InvalidParametersError
+		if !errors.As(err, &paramsErr) {
+			return ctrl.Result{}, err
+		}
+		newCondition.Status = metav1.ConditionFalse
+		newCondition.Reason = string(gatewayv1.GatewayClassReasonInvalidParameters)
+		newCondition.Message = paramsErr.Error()
 	}
 	meta.SetStatusCondition(&gc.Status.Conditions, newCondition)
 
 	if err := r.Status().Update(ctx, &gc); err != nil {
 		return ctrl.Result{}, fmt.Errorf("failed to update GatewayClass status: %w", err)
 	}
-	log.V(1).Info("Set Accepted=True for GatewayClass", "name", gc.Name)
+	log.V(1).Info("Set Accepted for GatewayClass", "name", gc.Name, "status", newCondition.Status)
 	return ctrl.Result{}, nil
 }
 
+// SetupWithManager sets up controller with Manager.
+// Class parameters are watched only if their CRD is installed.
 func (r *GatewayClassReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&gatewayv1.GatewayClass{}).
+	installed, err := classConfigInstalled(mgr.GetRESTMapper())
+	if err != nil {
+		return err
+	}
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&gatewayv1.GatewayClass{})
+	if installed {
+		b = b.Watches(
+			&v1alpha1.ServerscomGatewayClassConfig{},
+			handler.EnqueueRequestsFromMapFunc(r.findClassesForParams),
+		)
+	} else {
+		mgr.GetLogger().Info("ServerscomGatewayClassConfig CRD is not installed, GatewayClass parameters are not watched", "level", "warn")
+	}
+	return b.
 		WithEventFilter(predicate.GenerationChangedPredicate{}).
 		Complete(r)
 }
+
+// findClassesForParams returns reconcile requests for GatewayClasses referencing changed parameters
+func (r *GatewayClassReconciler) findClassesForParams(ctx context.Context, obj client.Object) []reconcile.Request {
+	names, err := gatewayClassesForParams(ctx, r.Client, obj.GetName())
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list GatewayClasses")
+		return nil
+	}
+	var reqs []reconcile.Request
+	for _, name := range names {
+		reqs = append(reqs, reconcile.Request{NamespacedName: client.ObjectKey{Name: name}})
+	}
+	return reqs
+}
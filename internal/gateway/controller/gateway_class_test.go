@@ -4,7 +4,10 @@ import (
 	"context"
 	"testing"
 
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+
 	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -84,3 +87,50 @@ func Test_GatewayClassReconciler_Reconcile_SkipsOtherController(t *testing.T) {
 	g.Expect(fakeCli.Get(context.Background(), types.NamespacedName{Name: gc.Name}, &got)).To(Succeed())
 	g.Expect(len(got.Status.Conditions)).To(Equal(0))
 }
+
+func Test_GatewayClassReconciler_Reconcile_InvalidParameters(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	gc := gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "test-gc",
+		},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("example.com/controller"),
+			ParametersRef:  paramsRef("missing"),
+		},
+	}
+
+	fakeCli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&gatewayv1.GatewayClass{}).
+		WithObjects(&gc).
+		Build()
+
+	r := &GatewayClassReconciler{
+		Client:         fakeCli,
+		ControllerName: "example.com/controller",
+	}
+
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Name: gc.Name}}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(BeNil())
+
+	var got gatewayv1.GatewayClass
+	g.Expect(fakeCli.Get(context.Background(), types.NamespacedName{Name: gc.Name}, &got)).To(Succeed())
+	cond := meta.FindStatusCondition(got.Status.Conditions, string(gatewayv1.GatewayClassConditionStatusAccepted))
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(Equal(string(gatewayv1.GatewayClassReasonInvalidParameters)))
+
+	// parameters created, class is accepted
+	cfg := &v1alpha1.ServerscomGatewayClassConfig{ObjectMeta: metav1.ObjectMeta{Name: "missing"}}
+	g.Expect(fakeCli.Create(context.Background(), cfg)).To(Succeed())
+	g.Expect(r.findClassesForParams(context.Background(), cfg)).To(Equal([]ctrl.Request{req}))
+	_, err = r.Reconcile(context.Background(), req)
+	g.Expect(err).To(BeNil())
+
+	g.Expect(fakeCli.Get(context.Background(), types.NamespacedName{Name: gc.Name}, &got)).To(Succeed())
+	g.Expect(meta.IsStatusConditionTrue(got.Status.Conditions, string(gatewayv1.GatewayClassConditionStatusAccepted))).To(BeTrue())
+}
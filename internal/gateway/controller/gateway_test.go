@@ -2,15 +2,23 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
 	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/metrics"
 	"github.com/serverscom/api-gateway-controller/internal/mocks"
+	gwtypes "github.com/serverscom/api-gateway-controller/internal/types"
 
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	serverscom "github.com/serverscom/serverscom-go-client/pkg"
 	"go.uber.org/mock/gomock"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -19,7 +27,9 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
 )
 
 var (
@@ -32,7 +42,9 @@ func setupScheme(t *testing.T) *runtime.Scheme {
 	scheme := runtime.NewScheme()
 	g.Expect(clientgoscheme.AddToScheme(scheme)).To(BeNil())
 	g.Expect(gatewayv1.Install(scheme)).To(BeNil())
+	g.Expect(gatewayv1beta1.Install(scheme)).To(BeNil())
 	g.Expect(corev1.AddToScheme(scheme)).To(BeNil())
+	g.Expect(v1alpha1.AddToScheme(scheme)).To(BeNil())
 	return scheme
 }
 
@@ -190,6 +202,23 @@ func TestReconcile(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "invalid gateway class parameters",
+			prepareObjs: func() []client.Object {
+				gc := baseGC.DeepCopy()
+				gc.Spec.ParametersRef = paramsRef("missing")
+				return []client.Object{gc, baseGW.DeepCopy()}
+			},
+			setupMocks: func(tls *mocks.MockTLSManagerInterface, lb *mocks.MockLBManagerInterface) {},
+			checkStatus: func(t *testing.T, cli client.Client) {
+				var gw gatewayv1.Gateway
+				_ = cli.Get(context.Background(), types.NamespacedName{Name: testGw, Namespace: testGwNs}, &gw)
+				cond := meta.FindStatusCondition(gw.Status.Conditions, "Accepted")
+				if cond == nil || cond.Status != metav1.ConditionFalse || cond.Reason != string(gatewayv1.GatewayReasonInvalidParameters) {
+					t.Errorf("expected Accepted=False, Reason=InvalidParameters, got %v", cond)
+				}
+			},
+		},
 		{
 			name: "not managed gateway",
 			prepareObjs: func() []client.Object {
@@ -266,6 +295,62 @@ func TestReconcile(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "https listener missing secret does not fail gateway",
+			prepareObjs: func() []client.Object {
+				gw := baseGW.DeepCopy()
+				term := gatewayv1.TLSModeTerminate
+				gw.Spec.Listeners = []gatewayv1.Listener{
+					{
+						Name:     "http",
+						Port:     80,
+						Protocol: gatewayv1.HTTPProtocolType,
+					},
+					{
+						Name:     "https",
+						Port:     443,
+						Protocol: gatewayv1.HTTPSProtocolType,
+						Hostname: ptrHostname("foo.com"),
+						TLS: &gatewayv1.GatewayTLSConfig{
+							Mode: &term,
+							CertificateRefs: []gatewayv1.SecretObjectReference{
+								{Name: "missing"},
+							},
+						},
+					},
+				}
+				return []client.Object{baseGC.DeepCopy(), gw}
+			},
+			setupMocks: func(tls *mocks.MockTLSManagerInterface, lb *mocks.MockLBManagerInterface) {
+				tls.EXPECT().
+					EnsureTLS(gomock.Any(), gomock.Len(0)).
+					Return(map[string]string{}, nil)
+				lb.EXPECT().
+					EnsureLB(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&serverscom.L7LoadBalancer{ID: "lb-6", Status: config.LB_ACTIVE_STATUS}, nil)
+			},
+			checkStatus: func(t *testing.T, cli client.Client) {
+				g := NewWithT(t)
+				var gw gatewayv1.Gateway
+				g.Expect(cli.Get(context.Background(), types.NamespacedName{Name: testGw, Namespace: testGwNs}, &gw)).To(Succeed())
+				g.Expect(meta.IsStatusConditionTrue(gw.Status.Conditions, "Accepted")).To(BeTrue())
+				g.Expect(meta.IsStatusConditionTrue(gw.Status.Conditions, "Programmed")).To(BeTrue())
+				g.Expect(gw.Status.Listeners).To(HaveLen(2))
+
+				httpStatus := gw.Status.Listeners[0]
+				g.Expect(httpStatus.Name).To(Equal(gatewayv1.SectionName("http")))
+				g.Expect(httpStatus.SupportedKinds).To(HaveLen(1))
+				g.Expect(meta.IsStatusConditionTrue(httpStatus.Conditions, "Programmed")).To(BeTrue())
+				g.Expect(meta.IsStatusConditionFalse(httpStatus.Conditions, "Conflicted")).To(BeTrue())
+
+				httpsStatus := gw.Status.Listeners[1]
+				resolved := meta.FindStatusCondition(httpsStatus.Conditions, "ResolvedRefs")
+				g.Expect(resolved).ToNot(BeNil())
+				g.Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+				g.Expect(resolved.Reason).To(Equal(string(gatewayv1.ListenerReasonInvalidCertificateRef)))
+				g.Expect(meta.IsStatusConditionFalse(httpsStatus.Conditions, "Programmed")).To(BeTrue())
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -273,6 +358,8 @@ func TestReconcile(t *testing.T) {
 			defer ctrlr.Finish()
 			mockTLS := mocks.NewMockTLSManagerInterface(ctrlr)
 			mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+			mockLB.EXPECT().ListManagedLBs(gomock.Any()).Return(nil, nil).AnyTimes()
+			mockTLS.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil).AnyTimes()
 			tt.setupMocks(mockTLS, mockLB)
 			fakeCli := fake.NewClientBuilder().
 				WithScheme(s).
@@ -287,6 +374,7 @@ func TestReconcile(t *testing.T) {
 				TLSMgr:           mockTLS,
 				LBMgr:            mockLB,
 				Recorder:         recorder,
+				Keys:             config.DefaultKeys(),
 			}
 			_, err := r.Reconcile(context.Background(), ctrl.Request{
 				NamespacedName: types.NamespacedName{
@@ -305,6 +393,102 @@ func TestReconcile(t *testing.T) {
 	}
 }
 
+func TestReconcile_BuildGatewayInfoErrors(t *testing.T) {
+	s := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: config.DEFAULT_GATEWAY_CLASS},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController(config.DEFAULT_CONTROLLER_NAME),
+		},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: testGw, Namespace: testGwNs, Finalizers: []string{config.GW_FINALIZER}},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: gatewayv1.ObjectName(config.DEFAULT_GATEWAY_CLASS),
+			Listeners: []gatewayv1.Listener{{
+				Name:     "http",
+				Port:     80,
+				Protocol: gatewayv1.HTTPProtocolType,
+			}},
+		},
+	}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: testGwNs},
+		Spec: corev1.ServiceSpec{
+			Type:                  corev1.ServiceTypeNodePort,
+			ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+			Ports:                 []corev1.ServicePort{{Port: 80, NodePort: 30080}},
+		},
+	}
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: testGwNs},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames:       []gatewayv1.Hostname{"example.com"},
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{Name: gatewayv1.ObjectName(testGw)}}},
+			Rules: []gatewayv1.HTTPRouteRule{{
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{BackendObjectReference: gatewayv1.BackendObjectReference{Name: "svc"}},
+				}},
+			}},
+		},
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testGwNs, Name: testGw}}
+	newReconciler := func(cli client.Client) *GatewayReconciler {
+		return &GatewayReconciler{
+			Client:           cli,
+			ControllerName:   config.DEFAULT_CONTROLLER_NAME,
+			GatewayClassName: config.DEFAULT_GATEWAY_CLASS,
+			Recorder:         record.NewFakeRecorder(16),
+			Keys:             config.DefaultKeys(),
+		}
+	}
+
+	t.Run("transient error is retried", func(t *testing.T) {
+		g := NewWithT(t)
+		fakeCli := fake.NewClientBuilder().
+			WithScheme(s).
+			WithStatusSubresource(&gatewayv1.Gateway{}).
+			WithObjects(gc.DeepCopy(), gw.DeepCopy(), svc, route, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}).
+			WithInterceptorFuncs(interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if _, ok := list.(*discoveryv1.EndpointSliceList); ok {
+						return errors.New("connection refused")
+					}
+					return c.List(ctx, list, opts...)
+				},
+			}).
+			Build()
+
+		_, err := newReconciler(fakeCli).Reconcile(context.Background(), req)
+		g.Expect(err).To(MatchError(ContainSubstring("connection refused")))
+
+		var got gatewayv1.Gateway
+		g.Expect(fakeCli.Get(context.Background(), req.NamespacedName, &got)).To(Succeed())
+		g.Expect(meta.FindStatusCondition(got.Status.Conditions, string(gatewayv1.GatewayConditionAccepted))).To(BeNil())
+	})
+
+	t.Run("invalid gateway is not accepted", func(t *testing.T) {
+		g := NewWithT(t)
+		invalid := gw.DeepCopy()
+		invalid.Spec.Listeners = append(invalid.Spec.Listeners, invalid.Spec.Listeners[0])
+		fakeCli := fake.NewClientBuilder().
+			WithScheme(s).
+			WithStatusSubresource(&gatewayv1.Gateway{}).
+			WithObjects(gc.DeepCopy(), invalid).
+			Build()
+
+		_, err := newReconciler(fakeCli).Reconcile(context.Background(), req)
+		g.Expect(err).To(BeNil())
+
+		var got gatewayv1.Gateway
+		g.Expect(fakeCli.Get(context.Background(), req.NamespacedName, &got)).To(Succeed())
+		accepted := meta.FindStatusCondition(got.Status.Conditions, string(gatewayv1.GatewayConditionAccepted))
+		g.Expect(accepted).ToNot(BeNil())
+		g.Expect(accepted.Status).To(Equal(metav1.ConditionFalse))
+		g.Expect(accepted.Message).To(ContainSubstring("duplicate listener name"))
+	})
+}
+
 func TestReconcile_LBBecomesActiveOnSecondPass(t *testing.T) {
 	s := setupScheme(t)
 	baseGC := &gatewayv1.GatewayClass{
@@ -336,6 +520,8 @@ func TestReconcile_LBBecomesActiveOnSecondPass(t *testing.T) {
 
 	mockTLS := mocks.NewMockTLSManagerInterface(ctrlr)
 	mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+	mockLB.EXPECT().ListManagedLBs(gomock.Any()).Return(nil, nil).AnyTimes()
+	mockTLS.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil).AnyTimes()
 	fakeCli := fake.NewClientBuilder().
 		WithScheme(s).
 		WithStatusSubresource(&gatewayv1.Gateway{}).
@@ -350,6 +536,7 @@ func TestReconcile_LBBecomesActiveOnSecondPass(t *testing.T) {
 		TLSMgr:           mockTLS,
 		LBMgr:            mockLB,
 		Recorder:         recorder,
+		Keys:             config.DefaultKeys(),
 	}
 
 	req := ctrl.Request{
@@ -455,14 +642,15 @@ func Test_buildTLSInfo(t *testing.T) {
 	r := &GatewayReconciler{Client: fakeCli}
 
 	// case 1: secret ref
-	tlsMap1, err := r.buildTLSInfo(context.Background(), gw1)
+	tlsMap1, tlsConds, err := r.buildTLSInfo(context.Background(), gw1)
 	g.Expect(err).To(BeNil())
 	g.Expect(tlsMap1).To(HaveKey("secret.com"))
 	g.Expect(tlsMap1["secret.com"].Secret).ToNot(BeNil())
 	g.Expect(tlsMap1["secret.com"].ExternalID).To(Equal(""))
+	g.Expect(tlsConds).To(BeEmpty())
 
 	// case 2: external id
-	tlsMap2, err := r.buildTLSInfo(context.Background(), gw2)
+	tlsMap2, _, err := r.buildTLSInfo(context.Background(), gw2)
 	g.Expect(err).To(BeNil())
 	g.Expect(tlsMap2).To(HaveKey("external.com"))
 	g.Expect(tlsMap2["external.com"].ExternalID).To(Equal("ext-cert-123"))
@@ -476,7 +664,8 @@ func Test_buildGatewayInfo(t *testing.T) {
 	node := &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
 		Status: corev1.NodeStatus{
-			Addresses: []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
 		},
 	}
 	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
@@ -583,19 +772,688 @@ func Test_buildGatewayInfo(t *testing.T) {
 	r := &GatewayReconciler{Client: fakeCli}
 
 	// case 1: HTTP
-	gi1, err := r.buildGatewayInfo(context.Background(), gw)
+	gi1, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
 	g.Expect(err).To(BeNil())
 	g.Expect(gi1.VHosts).To(HaveKey("example.com"))
 	g.Expect(gi1.VHosts["example.com"].SSL).To(BeFalse())
 
 	// case 2: HTTPS
-	gi2, err := r.buildGatewayInfo(context.Background(), gwTLS)
+	gi2, err := r.buildGatewayInfo(context.Background(), gwTLS, gwtypes.LBParams{}, nil)
 	g.Expect(err).To(BeNil())
 	g.Expect(gi2.VHosts).To(BeEmpty())
 
 	// case 3: unmatched host
-	gi3, err := r.buildGatewayInfo(context.Background(), gw)
+	gi3, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
 	g.Expect(err).To(BeNil())
 	g.Expect(gi3.VHosts).To(HaveKey("example.com"))
 	g.Expect(gi3.VHosts).ToNot(HaveKey("no-match.com"))
 }
+
+func Test_buildGatewayInfo_InvalidRoutes(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: testGwNs},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeNodePort,
+			Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080}},
+		},
+	}
+	svcClusterIP := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-cip", Namespace: testGwNs},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeClusterIP,
+			Ports: []corev1.ServicePort{{Port: 80}},
+		},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "l1",
+				Protocol: gatewayv1.HTTPProtocolType,
+				Port:     80,
+			}},
+		},
+	}
+	newRoute := func(name string, created time.Time, host, backend string, port *gatewayv1.PortNumber) *gatewayv1.HTTPRoute {
+		return &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testGwNs, CreationTimestamp: metav1.NewTime(created)},
+			Spec: gatewayv1.HTTPRouteSpec{
+				Hostnames: []gatewayv1.Hostname{gatewayv1.Hostname(host)},
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{
+					ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}},
+				},
+				Rules: []gatewayv1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1.HTTPBackendRef{{
+						BackendRef: gatewayv1.BackendRef{
+							BackendObjectReference: gatewayv1.BackendObjectReference{
+								Name: gatewayv1.ObjectName(backend),
+								Port: port,
+							},
+						},
+					}},
+				}},
+			},
+		}
+	}
+	now := time.Now().Truncate(time.Second)
+	port := gatewayv1.PortNumber(8080)
+	good := newRoute("good", now.Add(-time.Hour), "example.com", "svc", nil)
+	wildcard := newRoute("wildcard", now, "*.example.com", "svc", nil)
+	duplicate := newRoute("duplicate", now, "example.com", "svc", nil)
+	noNodePort := newRoute("no-nodeport", now, "cip.com", "svc-cip", nil)
+	noPort := newRoute("no-port", now, "port.com", "svc", &port)
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node, ns, svc, svcClusterIP, gw, good, wildcard, duplicate, noNodePort, noPort).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli}
+
+	gi, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(gi.VHosts).To(HaveKey("example.com"))
+	g.Expect(gi.VHosts["example.com"].Paths).To(HaveLen(1))
+	g.Expect(gi.VHosts["example.com"].Paths[0].Backends[0].NodePort).To(Equal(30080))
+	g.Expect(gi.Listeners["l1"].AttachedRoutes).To(Equal(int32(4)))
+
+	accepted := func(key string) *metav1.Condition {
+		g.Expect(gi.Routes).To(HaveKey(key))
+		g.Expect(gi.Routes[key].Parents).To(HaveLen(1))
+		return meta.FindStatusCondition(gi.Routes[key].Parents[0].Conditions, string(gatewayv1.RouteConditionAccepted))
+	}
+	resolved := func(key string) *metav1.Condition {
+		return meta.FindStatusCondition(gi.Routes[key].Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	}
+
+	g.Expect(accepted(testGwNs + "/good").Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(resolved(testGwNs + "/good").Status).To(Equal(metav1.ConditionTrue))
+
+	cond := accepted(testGwNs + "/wildcard")
+	g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+
+	// newer route with the same path is merged, conflicting path is ignored
+	g.Expect(accepted(testGwNs + "/duplicate").Status).To(Equal(metav1.ConditionTrue))
+	partial := meta.FindStatusCondition(gi.Routes[testGwNs+"/duplicate"].Parents[0].Conditions, string(gatewayv1.RouteConditionPartiallyInvalid))
+	g.Expect(partial).ToNot(BeNil())
+	g.Expect(partial.Status).To(Equal(metav1.ConditionTrue))
+
+	g.Expect(accepted(testGwNs + "/no-nodeport").Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(resolved(testGwNs + "/no-nodeport").Reason).To(Equal(string(RouteReasonNoNodePort)))
+	g.Expect(gi.VHosts["cip.com"].Paths).To(BeEmpty())
+
+	g.Expect(resolved(testGwNs + "/no-port").Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+	g.Expect(gi.VHosts["port.com"].Paths).To(BeEmpty())
+}
+
+func Test_buildGatewayInfo_PodUpstreams(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	headless := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "headless", Namespace: testGwNs},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Ports:     []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+	invalid := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "invalid",
+			Namespace:   testGwNs,
+			Annotations: map[string]string{config.UPSTREAM_MODE_KEY: "Direct"},
+		},
+		Spec: corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080}}},
+	}
+	portName := "http"
+	targetPort := int32(8080)
+	slice := newEndpointSlice("headless-a", "headless", newEndpoint("n1", true), newEndpoint("n2", false))
+	slice.Endpoints[1].Addresses = []string{"10.244.0.2"}
+	slice.Ports = []discoveryv1.EndpointPort{{Name: &portName, Port: &targetPort}}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{Name: "l1", Protocol: gatewayv1.HTTPProtocolType, Port: 80}},
+		},
+	}
+	newRoute := func(name, host, backend string) *gatewayv1.HTTPRoute {
+		return &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testGwNs},
+			Spec: gatewayv1.HTTPRouteSpec{
+				Hostnames:       []gatewayv1.Hostname{gatewayv1.Hostname(host)},
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}}},
+				Rules: []gatewayv1.HTTPRouteRule{{
+					BackendRefs: []gatewayv1.HTTPBackendRef{{
+						BackendRef: gatewayv1.BackendRef{
+							BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(backend)},
+						},
+					}},
+				}},
+			},
+		}
+	}
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(ns, headless, invalid, slice, gw, newRoute("pods", "pods.com", "headless"), newRoute("invalid", "invalid.com", "invalid")).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli}
+
+	gi, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{UpstreamMode: v1alpha1.UpstreamModePod}, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(gi.VHosts["pods.com"].Paths).To(HaveLen(1))
+	backend := gi.VHosts["pods.com"].Paths[0].Backends[0]
+	g.Expect(backend.Direct).To(BeTrue())
+	g.Expect(backend.ServicePort).To(Equal(int32(80)))
+	g.Expect(backend.PodEndpoints).To(Equal([]gwtypes.PodEndpoint{{IP: "10.244.0.1", Port: 8080}}))
+
+	resolved := meta.FindStatusCondition(gi.Routes[testGwNs+"/invalid"].Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	g.Expect(resolved.Reason).To(Equal(string(RouteReasonInvalidUpstreamMode)))
+	g.Expect(gi.VHosts["invalid.com"].Paths).To(BeEmpty())
+
+	// headless service has no NodePort in default mode
+	gi, err = r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	resolved = meta.FindStatusCondition(gi.Routes[testGwNs+"/pods"].Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	g.Expect(resolved.Reason).To(Equal(string(RouteReasonNoNodePort)))
+}
+
+func Test_buildGatewayInfo_MergeRoutes(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	svcA := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: testGwNs},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080}}},
+	}
+	svcB := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-b", Namespace: testGwNs},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30081}}},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "l1",
+				Protocol: gatewayv1.HTTPProtocolType,
+				Port:     80,
+			}},
+		},
+	}
+	newRoute := func(name string, created time.Time, backend string, paths ...string) *gatewayv1.HTTPRoute {
+		var matches []gatewayv1.HTTPRouteMatch
+		for _, p := range paths {
+			matches = append(matches, gatewayv1.HTTPRouteMatch{
+				Path: &gatewayv1.HTTPPathMatch{Value: &p},
+			})
+		}
+		return &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: testGwNs, CreationTimestamp: metav1.NewTime(created)},
+			Spec: gatewayv1.HTTPRouteSpec{
+				Hostnames: []gatewayv1.Hostname{"example.com"},
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{
+					ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}},
+				},
+				Rules: []gatewayv1.HTTPRouteRule{{
+					Matches: matches,
+					BackendRefs: []gatewayv1.HTTPBackendRef{{
+						BackendRef: gatewayv1.BackendRef{
+							BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(backend)},
+						},
+					}},
+				}},
+			},
+		}
+	}
+	now := time.Now().Truncate(time.Second)
+	// "b-old" is older, so it owns /api even though "a-new" goes first by name
+	older := newRoute("b-old", now.Add(-time.Hour), "svc-a", "/api")
+	newer := newRoute("a-new", now, "svc-b", "/api", "/web")
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node, ns, svcA, svcB, gw, older, newer).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli}
+
+	gi, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(gi.VHosts).To(HaveLen(1))
+	paths := gi.VHosts["example.com"].Paths
+	g.Expect(paths).To(HaveLen(2))
+	g.Expect(paths[0].Path).To(Equal("/api"))
+	g.Expect(paths[0].Backends[0].NodePort).To(Equal(30080))
+	g.Expect(paths[1].Path).To(Equal("/web"))
+	g.Expect(paths[1].Backends[0].NodePort).To(Equal(30081))
+
+	g.Expect(meta.FindStatusCondition(gi.Routes[testGwNs+"/b-old"].Parents[0].Conditions, string(gatewayv1.RouteConditionPartiallyInvalid))).To(BeNil())
+	partial := meta.FindStatusCondition(gi.Routes[testGwNs+"/a-new"].Parents[0].Conditions, string(gatewayv1.RouteConditionPartiallyInvalid))
+	g.Expect(partial).ToNot(BeNil())
+	g.Expect(partial.Message).To(ContainSubstring("example.com/api"))
+}
+
+func Test_buildGatewayInfo_PathMatchTypes(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: testGwNs},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080}}},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "l1",
+				Protocol: gatewayv1.HTTPProtocolType,
+				Port:     80,
+			}},
+		},
+	}
+	match := func(pathType gatewayv1.PathMatchType, value string) gatewayv1.HTTPRouteMatch {
+		return gatewayv1.HTTPRouteMatch{Path: &gatewayv1.HTTPPathMatch{Type: &pathType, Value: &value}}
+	}
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: testGwNs},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"example.com"},
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}},
+			},
+			Rules: []gatewayv1.HTTPRouteRule{{
+				Matches: []gatewayv1.HTTPRouteMatch{
+					match(gatewayv1.PathMatchPathPrefix, "/api"),
+					match(gatewayv1.PathMatchExact, "/healthz"),
+					match(gatewayv1.PathMatchRegularExpression, "^/v[0-9]{1,2}/"),
+					match(gatewayv1.PathMatchRegularExpression, `^/"v"`),
+				},
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{Name: "svc"},
+					},
+				}},
+			}},
+		},
+	}
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node, ns, svc, gw, route).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli}
+
+	gi, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	paths := gi.VHosts["example.com"].Paths
+	g.Expect(paths).To(HaveLen(3))
+	g.Expect(paths[0].PathType).To(Equal(gatewayv1.PathMatchPathPrefix))
+	g.Expect(paths[1].PathType).To(Equal(gatewayv1.PathMatchExact))
+	g.Expect(paths[2].PathType).To(Equal(gatewayv1.PathMatchRegularExpression))
+
+	conds := gi.Routes[testGwNs+"/r1"].Parents[0].Conditions
+	g.Expect(meta.IsStatusConditionTrue(conds, string(gatewayv1.RouteConditionAccepted))).To(BeTrue())
+	partial := meta.FindStatusCondition(conds, string(gatewayv1.RouteConditionPartiallyInvalid))
+	g.Expect(partial).ToNot(BeNil())
+	g.Expect(partial.Reason).To(Equal(string(gatewayv1.RouteReasonUnsupportedValue)))
+	g.Expect(partial.Message).To(ContainSubstring("double quotes"))
+}
+
+func Test_buildGatewayInfo_WeightedBackends(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	stable := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "stable", Namespace: testGwNs},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080}}},
+	}
+	canary := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: testGwNs},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30081}}},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "l1",
+				Protocol: gatewayv1.HTTPProtocolType,
+				Port:     80,
+			}},
+		},
+	}
+	backendRef := func(name string, weight *int32) gatewayv1.HTTPBackendRef {
+		return gatewayv1.HTTPBackendRef{
+			BackendRef: gatewayv1.BackendRef{
+				BackendObjectReference: gatewayv1.BackendObjectReference{Name: gatewayv1.ObjectName(name)},
+				Weight:                 weight,
+			},
+		}
+	}
+	weight := func(w int32) *int32 { return &w }
+	api, zero := "/api", "/zero"
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: testGwNs},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"example.com"},
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}},
+			},
+			Rules: []gatewayv1.HTTPRouteRule{
+				{
+					Matches: []gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{Value: &api}}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{
+						backendRef("stable", nil),
+						backendRef("canary", weight(0)),
+						backendRef("missing", weight(5)),
+					},
+				},
+				{
+					Matches: []gatewayv1.HTTPRouteMatch{{Path: &gatewayv1.HTTPPathMatch{Value: &zero}}},
+					BackendRefs: []gatewayv1.HTTPBackendRef{
+						backendRef("stable", weight(0)),
+					},
+				},
+			},
+		},
+	}
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node, ns, stable, canary, gw, route).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli}
+
+	gi, err := r.buildGatewayInfo(context.Background(), gw, gwtypes.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	paths := gi.VHosts["example.com"].Paths
+	g.Expect(paths).To(HaveLen(1))
+	g.Expect(paths[0].Path).To(Equal("/api"))
+	g.Expect(paths[0].Backends).To(HaveLen(2))
+	g.Expect(paths[0].Backends[0].Service.Name).To(Equal("stable"))
+	g.Expect(paths[0].Backends[0].Weight).To(Equal(int32(1)))
+	g.Expect(paths[0].Backends[1].Service.Name).To(Equal("canary"))
+	g.Expect(paths[0].Backends[1].Weight).To(Equal(int32(0)))
+
+	resolved := meta.FindStatusCondition(gi.Routes[testGwNs+"/r1"].Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	g.Expect(resolved.Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+	g.Expect(resolved.Message).To(HaveSuffix("its traffic share is served by other backends of the rule"))
+}
+
+func TestReconcile_DriftDetection(t *testing.T) {
+	s := setupScheme(t)
+	baseGC := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: config.DEFAULT_GATEWAY_CLASS,
+		},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController(config.DEFAULT_CONTROLLER_NAME),
+		},
+	}
+	baseGW := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       testGw,
+			Namespace:  testGwNs,
+			Finalizers: []string{config.GW_FINALIZER},
+		},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: gatewayv1.ObjectName(config.DEFAULT_GATEWAY_CLASS),
+			Listeners: []gatewayv1.Listener{{
+				Name:     "http",
+				Port:     80,
+				Protocol: gatewayv1.HTTPProtocolType,
+			}},
+		},
+		Status: gatewayv1.GatewayStatus{
+			Conditions: []metav1.Condition{{
+				Type:               string(gatewayv1.GatewayConditionProgrammed),
+				Status:             metav1.ConditionTrue,
+				Reason:             "Programmed",
+				LastTransitionTime: metav1.Now(),
+			}},
+		},
+	}
+	req := ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: testGwNs, Name: testGw},
+	}
+
+	tests := []struct {
+		name       string
+		reportOnly bool
+		drift      []string
+		expectLB   bool
+		wantEvent  string
+		wantMetric float64
+	}{
+		{
+			name:       "no drift",
+			expectLB:   true,
+			wantEvent:  "Synced",
+			wantMetric: 0,
+		},
+		{
+			name:       "drift repaired",
+			drift:      []string{"domains [], expected [example.com]"},
+			expectLB:   true,
+			wantEvent:  "DriftDetected",
+			wantMetric: 1,
+		},
+		{
+			name:       "drift reported only",
+			reportOnly: true,
+			drift:      []string{"modified outside of controller at 2025-01-01T00:00:00Z"},
+			expectLB:   true,
+			wantEvent:  "(report only)",
+			wantMetric: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			ctrlr := gomock.NewController(t)
+			defer ctrlr.Finish()
+
+			mockTLS := mocks.NewMockTLSManagerInterface(ctrlr)
+			mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+			mockLB.EXPECT().ListManagedLBs(gomock.Any()).Return(nil, nil).AnyTimes()
+			mockTLS.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil).AnyTimes()
+			fakeCli := fake.NewClientBuilder().
+				WithScheme(s).
+				WithStatusSubresource(&gatewayv1.Gateway{}).
+				WithObjects(baseGC.DeepCopy(), baseGW.DeepCopy()).
+				Build()
+			recorder := record.NewFakeRecorder(8)
+			r := &GatewayReconciler{
+				Client:             fakeCli,
+				ControllerName:     config.DEFAULT_CONTROLLER_NAME,
+				GatewayClassName:   config.DEFAULT_GATEWAY_CLASS,
+				TLSMgr:             mockTLS,
+				LBMgr:              mockLB,
+				Recorder:           recorder,
+				Keys:               config.DefaultKeys(),
+				DriftCheckInterval: time.Minute,
+				DriftReportOnly:    tt.reportOnly,
+			}
+			metrics.DriftDetectedTotal.Reset()
+
+			mockTLS.EXPECT().
+				EnsureTLS(gomock.Any(), gomock.Any()).
+				Return(map[string]string{}, nil)
+			mockLB.EXPECT().
+				DetectDrift(gomock.Any(), gomock.Any(), gomock.Any()).
+				Return(tt.drift, nil)
+			if tt.expectLB {
+				mockLB.EXPECT().
+					EnsureLB(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&serverscom.L7LoadBalancer{ID: "lb-1", Status: config.LB_ACTIVE_STATUS}, nil)
+			}
+
+			res, err := r.Reconcile(context.Background(), req)
+			g.Expect(err).To(BeNil())
+			g.Expect(res.RequeueAfter).To(Equal(time.Minute))
+			g.Expect(recorder.Events).To(Receive(ContainSubstring(tt.wantEvent)))
+			g.Expect(testutil.ToFloat64(metrics.DriftDetectedTotal.WithLabelValues(testGwNs, testGw))).To(Equal(tt.wantMetric))
+		})
+	}
+
+	t.Run("drift check throttled by interval", func(t *testing.T) {
+		g := NewWithT(t)
+		ctrlr := gomock.NewController(t)
+		defer ctrlr.Finish()
+
+		mockTLS := mocks.NewMockTLSManagerInterface(ctrlr)
+		mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+		mockLB.EXPECT().ListManagedLBs(gomock.Any()).Return(nil, nil).AnyTimes()
+		mockTLS.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil).AnyTimes()
+		mockTLS.EXPECT().EnsureTLS(gomock.Any(), gomock.Any()).Return(map[string]string{}, nil).Times(3)
+		mockLB.EXPECT().EnsureLB(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&serverscom.L7LoadBalancer{ID: "lb-1", Status: config.LB_ACTIVE_STATUS}, nil).Times(3)
+		mockLB.EXPECT().DetectDrift(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).Times(2)
+		fakeCli := fake.NewClientBuilder().
+			WithScheme(s).
+			WithStatusSubresource(&gatewayv1.Gateway{}).
+			WithObjects(baseGC.DeepCopy(), baseGW.DeepCopy()).
+			Build()
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		r := &GatewayReconciler{
+			Client:             fakeCli,
+			ControllerName:     config.DEFAULT_CONTROLLER_NAME,
+			GatewayClassName:   config.DEFAULT_GATEWAY_CLASS,
+			TLSMgr:             mockTLS,
+			LBMgr:              mockLB,
+			Recorder:           record.NewFakeRecorder(16),
+			Keys:               config.DefaultKeys(),
+			DriftCheckInterval: time.Minute,
+			now:                func() time.Time { return now },
+		}
+
+		// second reconcile within interval, e.g. triggered by route change, doesn't check drift
+		for _, step := range []time.Duration{0, 30 * time.Second, time.Minute} {
+			now = now.Add(step)
+			_, err := r.Reconcile(context.Background(), req)
+			g.Expect(err).To(BeNil())
+		}
+	})
+
+	t.Run("drift metric of cleaned up gateway is deleted", func(t *testing.T) {
+		g := NewWithT(t)
+		ctrlr := gomock.NewController(t)
+		defer ctrlr.Finish()
+
+		mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+		mockLB.EXPECT().DeleteLB(gomock.Any(), gomock.Any()).Return(nil)
+		gw := baseGW.DeepCopy()
+		gw.Spec.GatewayClassName = "some-other-class"
+		fakeCli := fake.NewClientBuilder().
+			WithScheme(s).
+			WithStatusSubresource(&gatewayv1.Gateway{}).
+			WithObjects(baseGC.DeepCopy(), gw).
+			Build()
+		r := &GatewayReconciler{
+			Client:             fakeCli,
+			ControllerName:     config.DEFAULT_CONTROLLER_NAME,
+			GatewayClassName:   config.DEFAULT_GATEWAY_CLASS,
+			LBMgr:              mockLB,
+			Recorder:           record.NewFakeRecorder(8),
+			Keys:               config.DefaultKeys(),
+			DriftCheckInterval: time.Minute,
+		}
+		metrics.DriftDetectedTotal.Reset()
+		metrics.DriftDetectedTotal.WithLabelValues(testGwNs, testGw).Inc()
+
+		_, err := r.Reconcile(context.Background(), req)
+		g.Expect(err).To(BeNil())
+		g.Expect(testutil.CollectAndCount(metrics.DriftDetectedTotal)).To(BeZero())
+	})
+}
+
+func TestReconcile_FinalizerMigration(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: config.DEFAULT_GATEWAY_CLASS},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController(config.DEFAULT_CONTROLLER_NAME),
+		},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       testGw,
+			Namespace:  testGwNs,
+			Finalizers: []string{config.GW_FINALIZER},
+		},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: gatewayv1.ObjectName(config.DEFAULT_GATEWAY_CLASS),
+			Listeners: []gatewayv1.Listener{{
+				Name:     "http",
+				Port:     80,
+				Protocol: gatewayv1.HTTPProtocolType,
+			}},
+		},
+	}
+
+	ctrlr := gomock.NewController(t)
+	defer ctrlr.Finish()
+	mockTLS := mocks.NewMockTLSManagerInterface(ctrlr)
+	mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+	mockLB.EXPECT().ListManagedLBs(gomock.Any()).Return(nil, nil).AnyTimes()
+	mockTLS.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil).AnyTimes()
+	mockTLS.EXPECT().EnsureTLS(gomock.Any(), gomock.Any()).Return(map[string]string{}, nil)
+	mockLB.EXPECT().
+		EnsureLB(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(&serverscom.L7LoadBalancer{ID: "lb-1", Status: config.LB_ACTIVE_STATUS}, nil)
+
+	fakeCli := fake.NewClientBuilder().
+		WithScheme(s).
+		WithStatusSubresource(&gatewayv1.Gateway{}).
+		WithObjects(gc, gw).
+		Build()
+	keys := config.DefaultKeys()
+	keys.Finalizer = "staging/gateway-cleanup"
+	keys.OldFinalizer = config.GW_FINALIZER
+	r := &GatewayReconciler{
+		Client:           fakeCli,
+		ControllerName:   config.DEFAULT_CONTROLLER_NAME,
+		GatewayClassName: config.DEFAULT_GATEWAY_CLASS,
+		TLSMgr:           mockTLS,
+		LBMgr:            mockLB,
+		Recorder:         record.NewFakeRecorder(8),
+		Keys:             keys,
+	}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testGwNs, Name: testGw}}
+	_, err := r.Reconcile(context.Background(), req)
+	g.Expect(err).To(BeNil())
+
+	var updated gatewayv1.Gateway
+	g.Expect(fakeCli.Get(context.Background(), req.NamespacedName, &updated)).To(Succeed())
+	g.Expect(updated.Finalizers).To(Equal([]string{"staging/gateway-cleanup"}))
+}
@@ -2,8 +2,12 @@ package controller
 
 import (
 	"context"
+	"slices"
+
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/client-go/tools/cache"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -17,7 +21,21 @@ func (r *GatewayReconciler) findGatewaysForHTTPRoute(ctx context.Context, obj cl
 	route := obj.(*gatewayv1.HTTPRoute)
 	var requests []reconcile.Request
 
+	// gateways from status should be reconciled too to clean up entries of removed parentRefs
 	parentKeys := r.getParentGatewayKeys(route)
+	for _, ps := range route.Status.Parents {
+		if string(ps.ControllerName) != r.ControllerName {
+			continue
+		}
+		ns := route.Namespace
+		if ps.ParentRef.Namespace != nil {
+			ns = string(*ps.ParentRef.Namespace)
+		}
+		key := ns + "/" + string(ps.ParentRef.Name)
+		if !slices.Contains(parentKeys, key) {
+			parentKeys = append(parentKeys, key)
+		}
+	}
 	for _, key := range parentKeys {
 		namespace, name, err := cache.SplitMetaNamespaceKey(key)
 		if err != nil {
@@ -114,8 +132,9 @@ func (r *GatewayReconciler) findGatewaysForSecret(ctx context.Context, obj clien
 	secret := obj.(*corev1.Secret)
 	var requests []reconcile.Request
 
+	// secret can be referenced from other namespaces through ReferenceGrant
 	var gateways gatewayv1.GatewayList
-	if err := r.List(ctx, &gateways, client.InNamespace(secret.Namespace)); err != nil {
+	if err := r.List(ctx, &gateways); err != nil {
 		ctrl.LoggerFrom(ctx).Error(err, "Failed to list Gateways for secret change", "secret", secret.Name)
 		return nil
 	}
@@ -144,6 +163,127 @@ func (r *GatewayReconciler) findGatewaysForSecret(ctx context.Context, obj clien
 	return requests
 }
 
+// findGatewaysForNode returns reconcile requests with all managed gateways, since every gateway uses all nodes as upstreams
+func (r *GatewayReconciler) findGatewaysForNode(ctx context.Context, obj client.Object) []reconcile.Request {
+	var gateways gatewayv1.GatewayList
+	if err := r.List(ctx, &gateways); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list Gateways for node change", "node", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, gw := range gateways.Items {
+		managed, err := r.isManagedGateway(ctx, &gw)
+		if err != nil {
+			ctrl.LoggerFrom(ctx).V(1).Info("Failed to check if gateway is managed", "node", obj.GetName(), "gateway", gw.Name, "error", err)
+			continue
+		}
+		if !managed {
+			continue
+		}
+
+		ctrl.LoggerFrom(ctx).V(3).Info("Node change triggers Gateway reconcile", "node", obj.GetName(), "gateway", gw.Name)
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKey{Namespace: gw.Namespace, Name: gw.Name},
+		})
+	}
+
+	return requests
+}
+
+// findGatewaysForNamespace returns reconcile requests with gateways that affected by changes in Namespace labels.
+// Labels are used by listeners allowedRoutes selectors, so only gateways with routes from namespace are affected.
+func (r *GatewayReconciler) findGatewaysForNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	var httpRoutes gatewayv1.HTTPRouteList
+	if err := r.List(ctx, &httpRoutes, client.InNamespace(obj.GetName())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list HTTPRoutes for namespace change", "namespace", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	processedGateways := make(map[string]bool)
+	for _, route := range httpRoutes.Items {
+		for _, req := range r.findGatewaysForHTTPRoute(ctx, &route) {
+			key := req.String()
+			if processedGateways[key] {
+				continue
+			}
+			processedGateways[key] = true
+			requests = append(requests, req)
+		}
+	}
+
+	return requests
+}
+
+// findGatewaysForGatewayClass returns reconcile requests with gateways of changed GatewayClass
+func (r *GatewayReconciler) findGatewaysForGatewayClass(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findGatewaysOfClasses(ctx, obj.GetName())
+}
+
+// findGatewaysForClassParams returns reconcile requests with gateways of classes referencing changed parameters
+func (r *GatewayReconciler) findGatewaysForClassParams(ctx context.Context, obj client.Object) []reconcile.Request {
+	classes, err := gatewayClassesForParams(ctx, r.Client, obj.GetName())
+	if err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list GatewayClasses for parameters change", "name", obj.GetName())
+		return nil
+	}
+	return r.findGatewaysOfClasses(ctx, classes...)
+}
+
+// findGatewaysForConfigMap returns reconcile requests with gateways referencing ConfigMap as infrastructure parameters
+func (r *GatewayReconciler) findGatewaysForConfigMap(ctx context.Context, obj client.Object) []reconcile.Request {
+	var gateways gatewayv1.GatewayList
+	if err := r.List(ctx, &gateways, client.InNamespace(obj.GetNamespace())); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list Gateways for ConfigMap change", "namespace", obj.GetNamespace())
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gw := range gateways.Items {
+		if gw.Spec.Infrastructure == nil || gw.Spec.Infrastructure.ParametersRef == nil {
+			continue
+		}
+		ref := gw.Spec.Infrastructure.ParametersRef
+		if ref.Group != "" || ref.Kind != "ConfigMap" || ref.Name != obj.GetName() {
+			continue
+		}
+		if managed, _ := r.isManagedGateway(ctx, &gw); !managed {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKey{Namespace: gw.Namespace, Name: gw.Name},
+		})
+	}
+	return requests
+}
+
+// findGatewaysOfClasses returns reconcile requests with managed gateways of given classes.
+// Gateways with finalizer are included too, they have to be cleaned up if class is no longer ours.
+func (r *GatewayReconciler) findGatewaysOfClasses(ctx context.Context, classes ...string) []reconcile.Request {
+	if len(classes) == 0 {
+		return nil
+	}
+	var gateways gatewayv1.GatewayList
+	if err := r.List(ctx, &gateways); err != nil {
+		ctrl.LoggerFrom(ctx).Error(err, "Failed to list Gateways for GatewayClass change")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, gw := range gateways.Items {
+		if !slices.Contains(classes, string(gw.Spec.GatewayClassName)) {
+			continue
+		}
+		managed, _ := r.isManagedGateway(ctx, &gw)
+		if !managed && !r.hasFinalizer(&gw) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKey{Namespace: gw.Namespace, Name: gw.Name},
+		})
+	}
+	return requests
+}
+
 // getParentGatewayKeys returns gateways for HTTPRoute
 func (r *GatewayReconciler) getParentGatewayKeys(route *gatewayv1.HTTPRoute) []string {
 	var keys []string
@@ -209,3 +349,44 @@ func (r *GatewayReconciler) gatewayReferencesSecret(gw *gatewayv1.Gateway, secre
 	}
 	return false
 }
+
+// findGatewaysForEndpointSlice returns reconcile requests with gateways that affected by changes in EndpointSlice.
+// Only Services with Local external traffic policy and Services in Pod upstream mode depend on endpoints.
+func (r *GatewayReconciler) findGatewaysForEndpointSlice(ctx context.Context, obj client.Object) []reconcile.Request {
+	svcName := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if svcName == "" {
+		return nil
+	}
+	var svc corev1.Service
+	if err := r.Get(ctx, client.ObjectKey{Namespace: obj.GetNamespace(), Name: svcName}, &svc); err != nil {
+		if !apierrors.IsNotFound(err) {
+			ctrl.LoggerFrom(ctx).V(1).Info("Failed to get service for EndpointSlice", "endpointSlice", obj.GetName(), "service", svcName, "error", err)
+		}
+		return nil
+	}
+	requests := r.findGatewaysForService(ctx, &svc)
+	if svc.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyLocal {
+		return requests
+	}
+
+	// upstream mode depends on class of gateway
+	var result []reconcile.Request
+	for _, req := range requests {
+		var gw gatewayv1.Gateway
+		if err := r.Get(ctx, req.NamespacedName, &gw); err != nil {
+			continue
+		}
+		var gc gatewayv1.GatewayClass
+		if err := r.Get(ctx, client.ObjectKey{Name: string(gw.Spec.GatewayClassName)}, &gc); err != nil {
+			continue
+		}
+		params, err := resolveClassParams(ctx, r.Client, &gc)
+		if err != nil {
+			continue
+		}
+		if mode, err := upstreamMode(&svc, params.UpstreamMode); err == nil && mode == v1alpha1.UpstreamModePod {
+			result = append(result, req)
+		}
+	}
+	return result
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 	"testing"
 
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+	"github.com/serverscom/api-gateway-controller/internal/config"
+
 	. "github.com/onsi/gomega"
 
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -177,6 +182,30 @@ func Test_findGatewaysForService(t *testing.T) {
 	reqs := r.findGatewaysForService(context.Background(), svc)
 	g.Expect(len(reqs)).To(Equal(1))
 	g.Expect(reqs[0].NamespacedName.Name).To(Equal("gw1"))
+
+	// endpoints affect only services with local external traffic policy
+	slice := &discoveryv1.EndpointSlice{ObjectMeta: metav1.ObjectMeta{
+		Name:      "svc-abc",
+		Namespace: "gw-ns",
+		Labels:    map[string]string{discoveryv1.LabelServiceName: "svc"},
+	}}
+	g.Expect(r.findGatewaysForEndpointSlice(context.Background(), slice)).To(BeEmpty())
+
+	var local corev1.Service
+	g.Expect(fakeCli.Get(context.Background(), client.ObjectKeyFromObject(svc), &local)).To(Succeed())
+	local.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyLocal
+	g.Expect(fakeCli.Update(context.Background(), &local)).To(Succeed())
+	reqs = r.findGatewaysForEndpointSlice(context.Background(), slice)
+	g.Expect(len(reqs)).To(Equal(1))
+	g.Expect(reqs[0].NamespacedName.Name).To(Equal("gw1"))
+
+	// and services in pod upstream mode
+	local.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyCluster
+	local.Annotations = map[string]string{config.UPSTREAM_MODE_KEY: string(v1alpha1.UpstreamModePod)}
+	g.Expect(fakeCli.Update(context.Background(), &local)).To(Succeed())
+	reqs = r.findGatewaysForEndpointSlice(context.Background(), slice)
+	g.Expect(len(reqs)).To(Equal(1))
+	g.Expect(reqs[0].NamespacedName.Name).To(Equal("gw1"))
 }
 
 func Test_findGatewaysForSecret(t *testing.T) {
@@ -217,3 +246,176 @@ func Test_findGatewaysForSecret(t *testing.T) {
 	g.Expect(len(reqs)).To(Equal(1))
 	g.Expect(reqs[0].NamespacedName.Name).To(Equal("gw1"))
 }
+
+func Test_findGatewaysForNode(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc1"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("example.com/controller"),
+		},
+	}
+	gcOther := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc2"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("other.io/controller"),
+		},
+	}
+	gw1 := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: "ns1"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc1"},
+	}
+	gw2 := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw2", Namespace: "ns2"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc1"},
+	}
+	gwOther := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw3", Namespace: "ns1"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc2"},
+	}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "n1"}}
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(gc, gcOther, gw1, gw2, gwOther, node).
+		Build()
+	r := &GatewayReconciler{
+		Client:         fakeCli,
+		ControllerName: "example.com/controller",
+	}
+	reqs := r.findGatewaysForNode(context.Background(), node)
+	var names []string
+	for _, req := range reqs {
+		names = append(names, req.Name)
+	}
+	g.Expect(names).To(ConsistOf("gw1", "gw2"))
+}
+
+func Test_findGatewaysForNamespace(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc1"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("example.com/controller"),
+		},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: "gw-ns"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc1"},
+	}
+	gwNS := gatewayv1.Namespace("gw-ns")
+	newRoute := func(name, ns string) *gatewayv1.HTTPRoute {
+		return &gatewayv1.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: gatewayv1.HTTPRouteSpec{
+				CommonRouteSpec: gatewayv1.CommonRouteSpec{
+					ParentRefs: []gatewayv1.ParentReference{{Name: "gw1", Namespace: &gwNS}},
+				},
+			},
+		}
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}}
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(gc, gw, ns, newRoute("r1", "team-a"), newRoute("r2", "team-a"), newRoute("r3", "team-b")).
+		Build()
+	r := &GatewayReconciler{
+		Client:         fakeCli,
+		ControllerName: "example.com/controller",
+	}
+	reqs := r.findGatewaysForNamespace(context.Background(), ns)
+	g.Expect(reqs).To(HaveLen(1))
+	g.Expect(reqs[0].Name).To(Equal("gw1"))
+
+	reqs = r.findGatewaysForNamespace(context.Background(), &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "empty"}})
+	g.Expect(reqs).To(BeEmpty())
+}
+
+func Test_findGatewaysForGatewayClass(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc1"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("example.com/controller"),
+			ParametersRef:  paramsRef("cfg"),
+		},
+	}
+	gcOther := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc2"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("other.io/controller"),
+		},
+	}
+	gw1 := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: "ns1"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc1"},
+	}
+	gwOther := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw2", Namespace: "ns1"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc2"},
+	}
+	// class was switched to another controller, gateway still has to be cleaned up
+	gwReleased := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw3", Namespace: "ns1", Finalizers: []string{config.GW_FINALIZER}},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc2"},
+	}
+	cfg := &v1alpha1.ServerscomGatewayClassConfig{ObjectMeta: metav1.ObjectMeta{Name: "cfg"}}
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(gc, gcOther, gw1, gwOther, gwReleased, cfg).
+		Build()
+	r := &GatewayReconciler{
+		Client:         fakeCli,
+		ControllerName: "example.com/controller",
+		Keys:           config.DefaultKeys(),
+	}
+
+	reqs := r.findGatewaysForGatewayClass(context.Background(), gc)
+	g.Expect(reqs).To(HaveLen(1))
+	g.Expect(reqs[0].Name).To(Equal("gw1"))
+
+	reqs = r.findGatewaysForGatewayClass(context.Background(), gcOther)
+	g.Expect(reqs).To(HaveLen(1))
+	g.Expect(reqs[0].Name).To(Equal("gw3"))
+
+	reqs = r.findGatewaysForClassParams(context.Background(), cfg)
+	g.Expect(reqs).To(HaveLen(1))
+	g.Expect(reqs[0].Name).To(Equal("gw1"))
+}
+
+func Test_findGatewaysForConfigMap(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc1"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("example.com/controller"),
+		},
+	}
+	newGateway := func(name, ns, cm string) *gatewayv1.Gateway {
+		return &gatewayv1.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+			Spec: gatewayv1.GatewaySpec{
+				GatewayClassName: "gc1",
+				Infrastructure: &gatewayv1.GatewayInfrastructure{
+					ParametersRef: &gatewayv1.LocalParametersReference{Kind: "ConfigMap", Name: cm},
+				},
+			},
+		}
+	}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "lb-params", Namespace: "ns1"}}
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(gc, cm,
+			newGateway("gw1", "ns1", "lb-params"),
+			newGateway("gw2", "ns1", "other"),
+			newGateway("gw3", "ns2", "lb-params"),
+		).
+		Build()
+	r := &GatewayReconciler{
+		Client:         fakeCli,
+		ControllerName: "example.com/controller",
+	}
+
+	reqs := r.findGatewaysForConfigMap(context.Background(), cm)
+	g.Expect(reqs).To(HaveLen(1))
+	g.Expect(reqs[0].Name).To(Equal("gw1"))
+}
@@ -1,38 +1,301 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/serverscom/api-gateway-controller/internal/types"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
+// refError describes a route reference that can't be resolved.
+// Reason is used in route ResolvedRefs condition.
+type refError struct {
+	Reason  gatewayv1.RouteConditionReason
+	Message string
+}
+
+func (e *refError) Error() string {
+	return e.Message
+}
+
+// invalidGatewayError describes Gateway spec which can't be programmed, such Gateway is not accepted.
+// Other errors of building gateway info are transient and retried.
+type invalidGatewayError struct {
+	Message string
+}
+
+func (e *invalidGatewayError) Error() string {
+	return e.Message
+}
+
 // isRouteAttachedToGateway returns true if route is attached to Gateway
 func isRouteAttachedToGateway(route *gatewayv1.HTTPRoute, gw *gatewayv1.Gateway) bool {
 	for _, parent := range route.Spec.ParentRefs {
-		if parent.Kind != nil && string(*parent.Kind) != "Gateway" {
-			continue
+		if parentRefMatchesGateway(parent, route.Namespace, gw) {
+			return true
 		}
-		if parent.Group != nil && *parent.Group != gatewayv1.GroupName {
-			continue
+	}
+	return false
+}
+
+// parentRefMatchesGateway returns true if parentRef of route from routeNS points to Gateway
+func parentRefMatchesGateway(parent gatewayv1.ParentReference, routeNS string, gw *gatewayv1.Gateway) bool {
+	if parent.Kind != nil && string(*parent.Kind) != "Gateway" {
+		return false
+	}
+	if parent.Group != nil && *parent.Group != gatewayv1.GroupName {
+		return false
+	}
+	if string(parent.Name) != gw.Name {
+		return false
+	}
+	ns := routeNS
+	if parent.Namespace != nil {
+		ns = string(*parent.Namespace)
+	}
+	return ns == gw.Namespace
+}
+
+// hasRouteParentStatus returns true if route has status entry written by controllerName for Gateway
+func hasRouteParentStatus(route *gatewayv1.HTTPRoute, gw *gatewayv1.Gateway, controllerName string) bool {
+	for _, ps := range route.Status.Parents {
+		if string(ps.ControllerName) == controllerName && parentRefMatchesGateway(ps.ParentRef, route.Namespace, gw) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortRoutes sorts routes by creation time, oldest first, then by namespace/name.
+// Older route wins if routes conflict.
+func sortRoutes(routes []gatewayv1.HTTPRoute) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		ti, tj := routes[i].CreationTimestamp, routes[j].CreationTimestamp
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
 		}
-		if string(parent.Name) != gw.Name {
+		if routes[i].Namespace != routes[j].Namespace {
+			return routes[i].Namespace < routes[j].Namespace
+		}
+		return routes[i].Name < routes[j].Name
+	})
+}
+
+// validateRouteHostnames returns route hostnames.
+// Only concrete hostnames are supported: no wildcards, no empty values.
+func validateRouteHostnames(route *gatewayv1.HTTPRoute) ([]string, error) {
+	if len(route.Spec.Hostnames) == 0 {
+		return nil, fmt.Errorf("hostname must be specified (no wildcards, no empty values supported)")
+	}
+	var hostnames []string
+	for _, h := range route.Spec.Hostnames {
+		host := string(h)
+		if host == "" || strings.ContainsRune(host, '*') {
+			return nil, fmt.Errorf("invalid hostname %q (must be concrete, no wildcards, no empty)", host)
+		}
+		hostnames = append(hostnames, host)
+	}
+	return hostnames, nil
+}
+
+// mergeRoutePaths adds route paths to vhost.
+// Paths already present in vhost are skipped and returned as conflicts.
+func mergeRoutePaths(vh *types.VHostInfo, paths []types.PathInfo) []string {
+	type pathKey struct {
+		pathType gatewayv1.PathMatchType
+		path     string
+	}
+	keyOf := func(p types.PathInfo) pathKey {
+		pathType := p.PathType
+		if pathType == "" {
+			pathType = gatewayv1.PathMatchPathPrefix
+		}
+		return pathKey{pathType: pathType, path: p.Path}
+	}
+	existing := make(map[pathKey]bool, len(vh.Paths))
+	for _, p := range vh.Paths {
+		existing[keyOf(p)] = true
+	}
+	var conflicts []string
+	for _, p := range paths {
+		key := keyOf(p)
+		if existing[key] {
+			conflicts = append(conflicts, p.Path)
 			continue
 		}
+		existing[key] = true
+		vh.Paths = append(vh.Paths, p)
+	}
+	return conflicts
+}
 
-		ns := route.Namespace
-		if parent.Namespace != nil {
-			ns = string(*parent.Namespace)
+// addBackend adds backend to list, weights of backends pointing to the same Service port are summed.
+func addBackend(backends []types.BackendInfo, b types.BackendInfo) []types.BackendInfo {
+	for i := range backends {
+		if backends[i].Service.Namespace == b.Service.Namespace &&
+			backends[i].Service.Name == b.Service.Name &&
+			backends[i].NodePort == b.NodePort &&
+			backends[i].ServicePort == b.ServicePort {
+			backends[i].Weight += b.Weight
+			return backends
 		}
-		if ns == gw.Namespace {
+	}
+	return append(backends, b)
+}
+
+// hasWeightedBackend returns true if at least one backend has non-zero weight.
+func hasWeightedBackend(backends []types.BackendInfo) bool {
+	for _, b := range backends {
+		if b.Weight > 0 {
 			return true
 		}
 	}
 	return false
 }
 
+// validatePathMatch checks that path match can be translated into LB location.
+// Regular expression is PCRE evaluated by LB, so its syntax isn't checked here, LB update fails on invalid one.
+func validatePathMatch(pathType gatewayv1.PathMatchType, value string) error {
+	switch pathType {
+	case gatewayv1.PathMatchPathPrefix, gatewayv1.PathMatchExact:
+		// paths are placed into LB location unquoted, so these characters would break config
+		if strings.ContainsAny(value, " \t\n\r;{}") {
+			return fmt.Errorf("path %q: whitespaces, ';' and braces are not supported", value)
+		}
+		if !strings.HasPrefix(value, "/") {
+			return fmt.Errorf("path %q: must start with '/'", value)
+		}
+	case gatewayv1.PathMatchRegularExpression:
+		if value == "" {
+			return fmt.Errorf("regular expression must not be empty")
+		}
+		// regular expression is placed into LB location in double quotes
+		if strings.ContainsAny(value, "\"\n\r") {
+			return fmt.Errorf("path %q: double quotes and line breaks are not supported", value)
+		}
+	default:
+		return fmt.Errorf("path %q: unsupported match type %q", value, pathType)
+	}
+	return nil
+}
+
+// rejectedRouteParents returns parent statuses with Accepted=False for each route parentRef pointing to Gateway.
+func rejectedRouteParents(route *gatewayv1.HTTPRoute, gw *gatewayv1.Gateway, reason gatewayv1.RouteConditionReason, message string) []types.RouteParentInfo {
+	var parents []types.RouteParentInfo
+	for _, pr := range route.Spec.ParentRefs {
+		if !parentRefMatchesGateway(pr, route.Namespace, gw) {
+			continue
+		}
+		parents = append(parents, types.RouteParentInfo{
+			ParentRef: pr,
+			Conditions: []metav1.Condition{{
+				Type:               string(gatewayv1.RouteConditionAccepted),
+				Status:             metav1.ConditionFalse,
+				Reason:             string(reason),
+				Message:            message,
+				ObservedGeneration: route.Generation,
+			}},
+		})
+	}
+	return parents
+}
+
+// attachRouteToListeners returns listeners which accept route through parentRef.
+// If there are no such listeners, reason and message explain why route is not accepted.
+func attachRouteToListeners(
+	parent gatewayv1.ParentReference,
+	listeners []types.ListenerInfo,
+	gwNS, routeNS string,
+	nsLabels map[string]string,
+	hostnames []string,
+) ([]types.ListenerInfo, gatewayv1.RouteConditionReason, string) {
+	var candidates []types.ListenerInfo
+	for _, l := range listeners {
+		if parent.SectionName != nil && string(*parent.SectionName) != l.Name {
+			continue
+		}
+		if parent.Port != nil && int32(*parent.Port) != l.Port {
+			continue
+		}
+		candidates = append(candidates, l)
+	}
+	if len(candidates) == 0 {
+		return nil, gatewayv1.RouteReasonNoMatchingParent, "No listener matches parentRef sectionName and port"
+	}
+
+	var allowed []types.ListenerInfo
+	for _, l := range candidates {
+		if l.Protocol != string(gatewayv1.HTTPProtocolType) && l.Protocol != string(gatewayv1.HTTPSProtocolType) {
+			continue
+		}
+		if isRouteNamespaceAllowed(l, gwNS, routeNS, nsLabels) {
+			allowed = append(allowed, l)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, gatewayv1.RouteReasonNotAllowedByListeners, fmt.Sprintf("HTTPRoute from namespace %q is not allowed by listeners", routeNS)
+	}
+
+	var matched []types.ListenerInfo
+	for _, l := range allowed {
+		for _, h := range hostnames {
+			if hostMatches(l.Hostname, h) {
+				matched = append(matched, l)
+				break
+			}
+		}
+	}
+	if len(matched) == 0 {
+		return nil, gatewayv1.RouteReasonNoMatchingListenerHostname, "No listener hostname matches HTTPRoute hostnames"
+	}
+	return matched, gatewayv1.RouteReasonAccepted, "HTTPRoute is accepted"
+}
+
+// resolvedRefsCondition builds route ResolvedRefs condition from reference errors.
+// Reason of the first error is used, messages of all errors are joined.
+func resolvedRefsCondition(errs []*refError, generation int64) metav1.Condition {
+	cond := metav1.Condition{
+		Type:               string(gatewayv1.RouteConditionResolvedRefs),
+		Status:             metav1.ConditionTrue,
+		Reason:             string(gatewayv1.RouteReasonResolvedRefs),
+		Message:            "All references are resolved",
+		ObservedGeneration: generation,
+	}
+	if len(errs) == 0 {
+		return cond
+	}
+	msgs := make([]string, 0, len(errs))
+	for _, e := range errs {
+		msgs = append(msgs, e.Message)
+	}
+	cond.Status = metav1.ConditionFalse
+	cond.Reason = string(errs[0].Reason)
+	cond.Message = strings.Join(msgs, "; ")
+	return cond
+}
+
+// mergeConditions returns desired conditions keeping LastTransitionTime of unchanged ones from existing.
+// Conditions missing in desired are dropped.
+func mergeConditions(existing, desired []metav1.Condition) []metav1.Condition {
+	res := make([]metav1.Condition, 0, len(desired))
+	for _, c := range existing {
+		if meta.FindStatusCondition(desired, c.Type) != nil {
+			res = append(res, c)
+		}
+	}
+	for _, c := range desired {
+		meta.SetStatusCondition(&res, c)
+	}
+	return res
+}
+
 // isRouteNamespaceAllowed returns true if route's namespace is permitted by the listener policy.
 func isRouteNamespaceAllowed(listener types.ListenerInfo, listenerNS, routeNS string, nsLabels map[string]string) bool {
 	switch listener.AllowedFrom {
@@ -79,6 +342,24 @@ func joinErrors(errs []error) string {
 	return b.String()
 }
 
+// syncFailedReason returns ClusterConflict reason if provider resource belongs to another cluster,
+// ImmutableFieldChanged if load balancer can't be updated to desired state, otherwise returns defaultReason.
+func syncFailedReason(err error, defaultReason string) string {
+	var conflictErr *utils.ClusterConflictError
+	if errors.As(err, &conflictErr) {
+		return "ClusterConflict"
+	}
+	var immutableErr *utils.ImmutableChangeError
+	if errors.As(err, &immutableErr) {
+		return "ImmutableFieldChanged"
+	}
+	var timeoutErr *utils.ReplacementTimeoutError
+	if errors.As(err, &timeoutErr) {
+		return "ReplacementTimedOut"
+	}
+	return defaultReason
+}
+
 // hostMatches reports whether routeHost matches listenerHost, supporting wildcards.
 func hostMatches(listenerHost, routeHost string) bool {
 	if listenerHost == "" {
@@ -93,3 +374,167 @@ func hostMatches(listenerHost, routeHost string) bool {
 	}
 	return false
 }
+
+// buildListenerStatusInfo evaluates listener conditions except Programmed and Conflicted.
+// tlsCond overrides condition of the same type if listener has invalid tls config.
+func buildListenerStatusInfo(l gatewayv1.Listener, tlsCond *metav1.Condition, generation int64) *types.ListenerStatusInfo {
+	info := &types.ListenerStatusInfo{SupportedKinds: []gatewayv1.RouteGroupKind{}}
+	accepted := metav1.Condition{
+		Type:    string(gatewayv1.ListenerConditionAccepted),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.ListenerReasonAccepted),
+		Message: "Listener is accepted",
+	}
+	resolved := metav1.Condition{
+		Type:    string(gatewayv1.ListenerConditionResolvedRefs),
+		Status:  metav1.ConditionTrue,
+		Reason:  string(gatewayv1.ListenerReasonResolvedRefs),
+		Message: "All references are resolved",
+	}
+
+	switch l.Protocol {
+	case gatewayv1.HTTPProtocolType, gatewayv1.HTTPSProtocolType:
+		kinds, ok := supportedRouteKinds(l.AllowedRoutes)
+		info.SupportedKinds = kinds
+		if !ok {
+			resolved.Status = metav1.ConditionFalse
+			resolved.Reason = string(gatewayv1.ListenerReasonInvalidRouteKinds)
+			resolved.Message = "Only HTTPRoute kind is supported"
+		}
+	default:
+		accepted.Status = metav1.ConditionFalse
+		accepted.Reason = string(gatewayv1.ListenerReasonUnsupportedProtocol)
+		accepted.Message = fmt.Sprintf("Protocol %q is not supported, only HTTP and HTTPS are supported", l.Protocol)
+	}
+	if tlsCond != nil {
+		switch tlsCond.Type {
+		case accepted.Type:
+			accepted = *tlsCond
+		case resolved.Type:
+			resolved = *tlsCond
+		}
+	}
+
+	accepted.ObservedGeneration = generation
+	resolved.ObservedGeneration = generation
+	info.Conditions = []metav1.Condition{accepted, resolved}
+	return info
+}
+
+// supportedRouteKinds returns route kinds supported by listener.
+// Returns false if allowedRoutes contains kinds that are not supported.
+func supportedRouteKinds(allowed *gatewayv1.AllowedRoutes) ([]gatewayv1.RouteGroupKind, bool) {
+	httpRoute := gatewayv1.RouteGroupKind{
+		Group: ptrGroup(gatewayv1.GroupName),
+		Kind:  "HTTPRoute",
+	}
+	if allowed == nil || len(allowed.Kinds) == 0 {
+		return []gatewayv1.RouteGroupKind{httpRoute}, true
+	}
+	kinds := []gatewayv1.RouteGroupKind{}
+	valid := true
+	for _, k := range allowed.Kinds {
+		if k.Kind == httpRoute.Kind && (k.Group == nil || *k.Group == gatewayv1.GroupName) {
+			if len(kinds) == 0 {
+				kinds = append(kinds, httpRoute)
+			}
+			continue
+		}
+		valid = false
+	}
+	return kinds, valid
+}
+
+// setListenerConflicts sets Conflicted condition for each listener.
+// Listeners on the same port must use the same protocol and distinct hostnames.
+func setListenerConflicts(listeners []gatewayv1.Listener, statuses map[string]*types.ListenerStatusInfo, generation int64) {
+	for i, l := range listeners {
+		cond := metav1.Condition{
+			Type:               string(gatewayv1.ListenerConditionConflicted),
+			Status:             metav1.ConditionFalse,
+			Reason:             string(gatewayv1.ListenerReasonNoConflicts),
+			Message:            "No conflicts found",
+			ObservedGeneration: generation,
+		}
+		for j, other := range listeners {
+			if i == j || l.Port != other.Port {
+				continue
+			}
+			if l.Protocol != other.Protocol {
+				cond.Status = metav1.ConditionTrue
+				cond.Reason = string(gatewayv1.ListenerReasonProtocolConflict)
+				cond.Message = fmt.Sprintf("Listener %q uses port %d with protocol %q", other.Name, other.Port, other.Protocol)
+				break
+			}
+			if ptrHostnameValue(l.Hostname) == ptrHostnameValue(other.Hostname) {
+				cond.Status = metav1.ConditionTrue
+				cond.Reason = string(gatewayv1.ListenerReasonHostnameConflict)
+				cond.Message = fmt.Sprintf("Listener %q uses port %d with the same hostname", other.Name, other.Port)
+				break
+			}
+		}
+		if info, ok := statuses[string(l.Name)]; ok {
+			info.Conditions = append(info.Conditions, cond)
+		}
+	}
+}
+
+// isListenerValid returns true if listener can be programmed on load balancer.
+func isListenerValid(info *types.ListenerStatusInfo) bool {
+	if info == nil || len(info.SupportedKinds) == 0 {
+		return false
+	}
+	if !meta.IsStatusConditionTrue(info.Conditions, string(gatewayv1.ListenerConditionAccepted)) {
+		return false
+	}
+	if meta.IsStatusConditionTrue(info.Conditions, string(gatewayv1.ListenerConditionConflicted)) {
+		return false
+	}
+	// listener with partially invalid route kinds still serves supported ones
+	resolved := meta.FindStatusCondition(info.Conditions, string(gatewayv1.ListenerConditionResolvedRefs))
+	if resolved != nil && resolved.Status != metav1.ConditionTrue && resolved.Reason != string(gatewayv1.ListenerReasonInvalidRouteKinds) {
+		return false
+	}
+	return true
+}
+
+// listenerErrors returns errors of listeners which can't be programmed
+func listenerErrors(listeners []gatewayv1.Listener, statuses map[string]*types.ListenerStatusInfo) []error {
+	var errs []error
+	for _, l := range listeners {
+		info := statuses[string(l.Name)]
+		if isListenerValid(info) {
+			continue
+		}
+		var msgs []string
+		if info != nil {
+			for _, c := range info.Conditions {
+				failed := c.Status == metav1.ConditionFalse
+				if c.Type == string(gatewayv1.ListenerConditionConflicted) {
+					failed = c.Status == metav1.ConditionTrue
+				}
+				if failed {
+					msgs = append(msgs, c.Message)
+				}
+			}
+		}
+		errs = append(errs, fmt.Errorf("listener %q: %s", l.Name, strings.Join(msgs, "; ")))
+	}
+	return errs
+}
+
+func ptrGroup(g string) *gatewayv1.Group {
+	group := gatewayv1.Group(g)
+	return &group
+}
+
+func ptrString(s string) *string {
+	return &s
+}
+
+func ptrHostnameValue(h *gatewayv1.Hostname) string {
+	if h == nil {
+		return ""
+	}
+	return string(*h)
+}
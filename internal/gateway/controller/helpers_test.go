@@ -2,11 +2,17 @@ package controller
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/serverscom/api-gateway-controller/internal/types"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
 
 	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
@@ -123,3 +129,231 @@ func TestIsRouteNamespaceAllowed(t *testing.T) {
 	nsLabels = map[string]string{"team": "beta"}
 	g.Expect(isRouteNamespaceAllowed(listener, "x", "y", nsLabels)).To(BeFalse())
 }
+
+func Test_attachRouteToListeners(t *testing.T) {
+	g := NewWithT(t)
+
+	listeners := []types.ListenerInfo{
+		{Name: "http", Protocol: "HTTP", Port: 80, AllowedFrom: "Same"},
+		{Name: "https", Protocol: "HTTPS", Port: 443, Hostname: "secure.com", AllowedFrom: "All"},
+	}
+	section := func(s string) *gatewayv1.SectionName { n := gatewayv1.SectionName(s); return &n }
+
+	// whole gateway, same namespace
+	matched, reason, _ := attachRouteToListeners(gatewayv1.ParentReference{Name: "gw"}, listeners, "ns", "ns", nil, []string{"example.com"})
+	g.Expect(reason).To(Equal(gatewayv1.RouteReasonAccepted))
+	g.Expect(matched).To(HaveLen(1))
+	g.Expect(matched[0].Name).To(Equal("http"))
+
+	// unknown section
+	_, reason, _ = attachRouteToListeners(gatewayv1.ParentReference{Name: "gw", SectionName: section("missing")}, listeners, "ns", "ns", nil, []string{"example.com"})
+	g.Expect(reason).To(Equal(gatewayv1.RouteReasonNoMatchingParent))
+
+	// namespace not allowed
+	_, reason, _ = attachRouteToListeners(gatewayv1.ParentReference{Name: "gw", SectionName: section("http")}, listeners, "ns", "other", nil, []string{"example.com"})
+	g.Expect(reason).To(Equal(gatewayv1.RouteReasonNotAllowedByListeners))
+
+	// hostname mismatch
+	_, reason, _ = attachRouteToListeners(gatewayv1.ParentReference{Name: "gw", SectionName: section("https")}, listeners, "ns", "other", nil, []string{"example.com"})
+	g.Expect(reason).To(Equal(gatewayv1.RouteReasonNoMatchingListenerHostname))
+}
+
+func Test_resolvedRefsCondition(t *testing.T) {
+	g := NewWithT(t)
+
+	cond := resolvedRefsCondition(nil, 2)
+	g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(cond.ObservedGeneration).To(Equal(int64(2)))
+
+	cond = resolvedRefsCondition([]*refError{
+		{Reason: gatewayv1.RouteReasonBackendNotFound, Message: "one"},
+		{Reason: gatewayv1.RouteReasonInvalidKind, Message: "two"},
+	}, 2)
+	g.Expect(cond.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(cond.Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+	g.Expect(cond.Message).To(ContainSubstring("one"))
+	g.Expect(cond.Message).To(ContainSubstring("two"))
+}
+
+func Test_mergeConditions(t *testing.T) {
+	g := NewWithT(t)
+
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	existing := []metav1.Condition{
+		{Type: "Accepted", Status: metav1.ConditionTrue, Reason: "Accepted", LastTransitionTime: past},
+		{Type: "Stale", Status: metav1.ConditionTrue, Reason: "Stale", LastTransitionTime: past},
+	}
+	desired := []metav1.Condition{
+		{Type: "Accepted", Status: metav1.ConditionTrue, Reason: "Accepted"},
+		{Type: "ResolvedRefs", Status: metav1.ConditionFalse, Reason: "BackendNotFound"},
+	}
+	res := mergeConditions(existing, desired)
+	g.Expect(res).To(HaveLen(2))
+	g.Expect(meta.FindStatusCondition(res, "Accepted").LastTransitionTime).To(Equal(past))
+	g.Expect(meta.FindStatusCondition(res, "ResolvedRefs")).ToNot(BeNil())
+	g.Expect(meta.FindStatusCondition(res, "Stale")).To(BeNil())
+}
+
+func Test_setListenerConflicts(t *testing.T) {
+	g := NewWithT(t)
+
+	listeners := []gatewayv1.Listener{
+		{Name: "http", Port: 80, Protocol: gatewayv1.HTTPProtocolType},
+		{Name: "https", Port: 80, Protocol: gatewayv1.HTTPSProtocolType, Hostname: ptrHostname("a.com")},
+		{Name: "a", Port: 8080, Protocol: gatewayv1.HTTPProtocolType, Hostname: ptrHostname("a.com")},
+		{Name: "b", Port: 8080, Protocol: gatewayv1.HTTPProtocolType, Hostname: ptrHostname("a.com")},
+		{Name: "c", Port: 9090, Protocol: gatewayv1.HTTPProtocolType},
+	}
+	statuses := map[string]*types.ListenerStatusInfo{}
+	for _, l := range listeners {
+		statuses[string(l.Name)] = buildListenerStatusInfo(l, nil, 1)
+	}
+	setListenerConflicts(listeners, statuses, 1)
+
+	reason := func(name string) string {
+		return meta.FindStatusCondition(statuses[name].Conditions, string(gatewayv1.ListenerConditionConflicted)).Reason
+	}
+	g.Expect(reason("http")).To(Equal(string(gatewayv1.ListenerReasonProtocolConflict)))
+	g.Expect(reason("https")).To(Equal(string(gatewayv1.ListenerReasonProtocolConflict)))
+	g.Expect(reason("a")).To(Equal(string(gatewayv1.ListenerReasonHostnameConflict)))
+	g.Expect(reason("b")).To(Equal(string(gatewayv1.ListenerReasonHostnameConflict)))
+	g.Expect(reason("c")).To(Equal(string(gatewayv1.ListenerReasonNoConflicts)))
+	g.Expect(isListenerValid(statuses["a"])).To(BeFalse())
+	g.Expect(isListenerValid(statuses["c"])).To(BeTrue())
+}
+
+func Test_buildListenerStatusInfo(t *testing.T) {
+	g := NewWithT(t)
+
+	// unsupported protocol
+	info := buildListenerStatusInfo(gatewayv1.Listener{Name: "tcp", Protocol: gatewayv1.TCPProtocolType}, nil, 1)
+	g.Expect(info.SupportedKinds).To(BeEmpty())
+	g.Expect(meta.IsStatusConditionFalse(info.Conditions, string(gatewayv1.ListenerConditionAccepted))).To(BeTrue())
+	g.Expect(isListenerValid(info)).To(BeFalse())
+
+	// partially invalid route kinds
+	info = buildListenerStatusInfo(gatewayv1.Listener{
+		Name:     "http",
+		Protocol: gatewayv1.HTTPProtocolType,
+		AllowedRoutes: &gatewayv1.AllowedRoutes{
+			Kinds: []gatewayv1.RouteGroupKind{{Kind: "HTTPRoute"}, {Kind: "TCPRoute"}},
+		},
+	}, nil, 1)
+	g.Expect(info.SupportedKinds).To(HaveLen(1))
+	resolved := meta.FindStatusCondition(info.Conditions, string(gatewayv1.ListenerConditionResolvedRefs))
+	g.Expect(resolved.Reason).To(Equal(string(gatewayv1.ListenerReasonInvalidRouteKinds)))
+	g.Expect(isListenerValid(info)).To(BeTrue())
+
+	// invalid certificate ref
+	tlsCond := metav1.Condition{
+		Type:   string(gatewayv1.ListenerConditionResolvedRefs),
+		Status: metav1.ConditionFalse,
+		Reason: string(gatewayv1.ListenerReasonInvalidCertificateRef),
+	}
+	info = buildListenerStatusInfo(gatewayv1.Listener{Name: "https", Protocol: gatewayv1.HTTPSProtocolType}, &tlsCond, 1)
+	resolved = meta.FindStatusCondition(info.Conditions, string(gatewayv1.ListenerConditionResolvedRefs))
+	g.Expect(resolved.Reason).To(Equal(string(gatewayv1.ListenerReasonInvalidCertificateRef)))
+	g.Expect(isListenerValid(info)).To(BeFalse())
+}
+
+func Test_sortRoutes(t *testing.T) {
+	g := NewWithT(t)
+
+	now := time.Now()
+	newRoute := func(ns, name string, created time.Time) gatewayv1.HTTPRoute {
+		return gatewayv1.HTTPRoute{ObjectMeta: metav1.ObjectMeta{Namespace: ns, Name: name, CreationTimestamp: metav1.NewTime(created)}}
+	}
+	routes := []gatewayv1.HTTPRoute{
+		newRoute("b", "r1", now),
+		newRoute("a", "r2", now),
+		newRoute("a", "r1", now),
+		newRoute("z", "r1", now.Add(-time.Hour)),
+	}
+	sortRoutes(routes)
+
+	var keys []string
+	for _, r := range routes {
+		keys = append(keys, r.Namespace+"/"+r.Name)
+	}
+	g.Expect(keys).To(Equal([]string{"z/r1", "a/r1", "a/r2", "b/r1"}))
+}
+
+func Test_mergeRoutePaths(t *testing.T) {
+	g := NewWithT(t)
+
+	vh := &types.VHostInfo{Paths: []types.PathInfo{{Path: "/api", NodeIps: []string{"1"}}}}
+	conflicts := mergeRoutePaths(vh, []types.PathInfo{
+		{Path: "/api", NodeIps: []string{"2"}},
+		{Path: "/web", NodeIps: []string{"2"}},
+		{Path: "/web", NodeIps: []string{"3"}},
+	})
+	g.Expect(conflicts).To(Equal([]string{"/api", "/web"}))
+	g.Expect(vh.Paths).To(Equal([]types.PathInfo{{Path: "/api", NodeIps: []string{"1"}}, {Path: "/web", NodeIps: []string{"2"}}}))
+}
+
+func Test_validatePathMatch(t *testing.T) {
+	g := NewWithT(t)
+
+	tests := []struct {
+		pathType gatewayv1.PathMatchType
+		value    string
+		wantErr  bool
+	}{
+		{gatewayv1.PathMatchPathPrefix, "/api", false},
+		{gatewayv1.PathMatchExact, "/healthz", false},
+		{gatewayv1.PathMatchExact, "healthz", true},
+		{gatewayv1.PathMatchPathPrefix, "/a b", true},
+		{gatewayv1.PathMatchPathPrefix, "/a;b", true},
+		{gatewayv1.PathMatchRegularExpression, "^/v[0-9]+/", false},
+		{gatewayv1.PathMatchRegularExpression, "", true},
+		{gatewayv1.PathMatchRegularExpression, "^/v[0-9]{1,2}/", false},
+		{gatewayv1.PathMatchRegularExpression, "^/(?!internal)", false},
+		{gatewayv1.PathMatchRegularExpression, "^/a b;c", false},
+		{gatewayv1.PathMatchRegularExpression, `^/"quoted"`, true},
+		{gatewayv1.PathMatchRegularExpression, "^/a\n", true},
+		{gatewayv1.PathMatchPathPrefix, "/{id}", true},
+		{gatewayv1.PathMatchExact, "/a}", true},
+		{"Unknown", "/", true},
+	}
+	for _, tt := range tests {
+		err := validatePathMatch(tt.pathType, tt.value)
+		if tt.wantErr {
+			g.Expect(err).To(HaveOccurred(), "%s %q", tt.pathType, tt.value)
+		} else {
+			g.Expect(err).ToNot(HaveOccurred(), "%s %q", tt.pathType, tt.value)
+		}
+	}
+
+	// exact and prefix matches on the same path don't conflict
+	vh := &types.VHostInfo{Paths: []types.PathInfo{{Path: "/api"}}}
+	g.Expect(mergeRoutePaths(vh, []types.PathInfo{{Path: "/api", PathType: gatewayv1.PathMatchExact}})).To(BeEmpty())
+	g.Expect(mergeRoutePaths(vh, []types.PathInfo{{Path: "/api", PathType: gatewayv1.PathMatchPathPrefix}})).To(Equal([]string{"/api"}))
+}
+
+func Test_addBackend(t *testing.T) {
+	g := NewWithT(t)
+
+	svcA := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "ns"}}
+	svcB := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "ns"}}
+
+	var backends []types.BackendInfo
+	backends = addBackend(backends, types.BackendInfo{Service: svcA, NodePort: 30080, Weight: 0})
+	g.Expect(hasWeightedBackend(backends)).To(BeFalse())
+	backends = addBackend(backends, types.BackendInfo{Service: svcB, NodePort: 30081, Weight: 2})
+	backends = addBackend(backends, types.BackendInfo{Service: svcA, NodePort: 30080, Weight: 3})
+	g.Expect(hasWeightedBackend(backends)).To(BeTrue())
+	g.Expect(backends).To(HaveLen(2))
+	g.Expect(backends[0].Weight).To(Equal(int32(3)))
+	g.Expect(backends[1].Weight).To(Equal(int32(2)))
+}
+
+func Test_syncFailedReason(t *testing.T) {
+	g := NewWithT(t)
+	conflict := fmt.Errorf("wrapped: %w", &utils.ClusterConflictError{Kind: "load balancer", Name: "lb", ClusterID: "other"})
+	g.Expect(syncFailedReason(conflict, "SyncFailed")).To(Equal("ClusterConflict"))
+	immutable := &utils.ImmutableChangeError{Name: "lb", Field: "cluster", Current: "a", Desired: "b"}
+	g.Expect(syncFailedReason(immutable, "SyncFailed")).To(Equal("ImmutableFieldChanged"))
+	timeout := &utils.ReplacementTimeoutError{Name: "lb", Target: "2-shared", Timeout: time.Minute}
+	g.Expect(syncFailedReason(timeout, "SyncFailed")).To(Equal("ReplacementTimedOut"))
+	g.Expect(syncFailedReason(errors.New("api error"), "SyncFailed")).To(Equal("SyncFailed"))
+}
@@ -0,0 +1,68 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NodeDrainReconciler stores drain start of cordoned upstream nodes in node annotation,
+// so drain survives controller restart. Gateway reconciler only reads the annotation,
+// nodes are not written while desired LB state is built.
+type NodeDrainReconciler struct {
+	client.Client
+	// NodeSelector limits nodes used as upstreams, nil selects all eligible nodes
+	NodeSelector labels.Selector
+	// Keys contains drain start annotation key of this controller instance
+	Keys config.Keys
+
+	now func() time.Time
+}
+
+// Reconcile annotates draining upstream node with drain start and removes annotation from node which isn't draining.
+func (r *NodeDrainReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var node corev1.Node
+	if err := r.Get(ctx, req.NamespacedName, &node); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	key := r.Keys.DrainStartedAnnotation()
+	value, annotated := node.Annotations[key]
+	_, err := time.Parse(time.RFC3339, value)
+	draining := isNodeUpstream(&node, r.NodeSelector) && isNodeDraining(&node)
+	if draining == annotated && (!draining || err == nil) {
+		return ctrl.Result{}, nil
+	}
+
+	orig := node.DeepCopy()
+	if draining {
+		if node.Annotations == nil {
+			node.Annotations = make(map[string]string)
+		}
+		node.Annotations[key] = r.now().Truncate(time.Second).Format(time.RFC3339)
+	} else {
+		delete(node.Annotations, key)
+	}
+	if err := r.Patch(ctx, &node, client.MergeFrom(orig)); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to update drain start of node %s: %w", node.Name, err)
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up controller with Manager.
+func (r *NodeDrainReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("node-drain").
+		For(&corev1.Node{}, builder.WithPredicates(nodeChangedPredicate(r.NodeSelector))).
+		Complete(r)
+}
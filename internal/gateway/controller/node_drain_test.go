@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNodeDrainReconciler(t *testing.T) {
+	s := setupScheme(t)
+	ctx := context.Background()
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	started := now.Add(-time.Minute).Format(time.RFC3339)
+
+	tests := []struct {
+		name     string
+		mutate   func(n *corev1.Node)
+		expected map[string]string
+	}{
+		{
+			name:   "active node isn't annotated",
+			mutate: func(n *corev1.Node) {},
+		},
+		{
+			name:     "drain start is stored",
+			mutate:   func(n *corev1.Node) { n.Spec.Unschedulable = true },
+			expected: map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: now.Format(time.RFC3339)},
+		},
+		{
+			name: "stored drain start is kept",
+			mutate: func(n *corev1.Node) {
+				n.Spec.Unschedulable = true
+				n.Annotations = map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: started}
+			},
+			expected: map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: started},
+		},
+		{
+			name: "invalid drain start is replaced",
+			mutate: func(n *corev1.Node) {
+				n.Spec.Unschedulable = true
+				n.Annotations = map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: "yesterday"}
+			},
+			expected: map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: now.Format(time.RFC3339)},
+		},
+		{
+			name: "drain start of uncordoned node is removed",
+			mutate: func(n *corev1.Node) {
+				n.Annotations = map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: started, "other": "kept"}
+			},
+			expected: map[string]string{"other": "kept"},
+		},
+		{
+			name: "node excluded from upstreams isn't drained",
+			mutate: func(n *corev1.Node) {
+				n.Spec.Unschedulable = true
+				n.Labels[config.NODE_EXCLUDE_LB_LABEL] = ""
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			node := newReadyNode("n1", "10.0.0.1")
+			tt.mutate(node)
+			c := fake.NewClientBuilder().WithScheme(s).WithObjects(node).Build()
+			r := &NodeDrainReconciler{
+				Client: c,
+				Keys:   config.DefaultKeys(),
+				now:    func() time.Time { return now },
+			}
+
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "n1"}})
+			g.Expect(err).To(BeNil())
+			g.Expect(c.Get(ctx, client.ObjectKeyFromObject(node), node)).To(Succeed())
+			if tt.expected == nil {
+				g.Expect(node.Annotations).To(BeEmpty())
+			} else {
+				g.Expect(node.Annotations).To(Equal(tt.expected))
+			}
+		})
+	}
+
+	t.Run("deleted node is ignored", func(t *testing.T) {
+		g := NewWithT(t)
+		r := &NodeDrainReconciler{Client: fake.NewClientBuilder().WithScheme(s).Build(), Keys: config.DefaultKeys()}
+		_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: "n1"}})
+		g.Expect(err).To(BeNil())
+	})
+}
@@ -0,0 +1,341 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// nodeIPs contains upstream IPs of nodes
+type nodeIPs struct {
+	active []string
+	// draining contains IPs of nodes which receive no new traffic until drain ends
+	draining []string
+	// drainUntil contains time drain ends by node name
+	drainUntil map[string]time.Time
+	// drained contains names of nodes removed from upstreams after drain
+	drained []string
+	// byName contains IPs of active and draining nodes by node name
+	byName map[string][]string
+}
+
+// NodeAddressPolicy defines which node addresses are used as upstreams.
+// Empty policy prefers external address to internal one and IPv4 to IPv6.
+type NodeAddressPolicy struct {
+	// Types is preference order of address types
+	Types []corev1.NodeAddressType
+	// Families is preference order of IP families, family is preferred over address type
+	Families []corev1.IPFamily
+	// DualStack uses address of every family node has instead of the first one
+	DualStack bool
+}
+
+// NewNodeAddressPolicy validates address types and IP families and returns policy with them
+func NewNodeAddressPolicy(addrTypes, families []string, dualStack bool) (NodeAddressPolicy, error) {
+	policy := NodeAddressPolicy{DualStack: dualStack}
+	for _, t := range addrTypes {
+		switch addrType := corev1.NodeAddressType(t); addrType {
+		case corev1.NodeExternalIP, corev1.NodeInternalIP:
+			policy.Types = append(policy.Types, addrType)
+		default:
+			return NodeAddressPolicy{}, fmt.Errorf("unsupported node address type %q, expected %s or %s",
+				t, corev1.NodeExternalIP, corev1.NodeInternalIP)
+		}
+	}
+	for _, f := range families {
+		switch family := corev1.IPFamily(f); family {
+		case corev1.IPv4Protocol, corev1.IPv6Protocol:
+			policy.Families = append(policy.Families, family)
+		default:
+			return NodeAddressPolicy{}, fmt.Errorf("unsupported IP family %q, expected %s or %s",
+				f, corev1.IPv4Protocol, corev1.IPv6Protocol)
+		}
+	}
+	return policy, nil
+}
+
+// addressTypes returns preference order of address types
+func (p NodeAddressPolicy) addressTypes() []corev1.NodeAddressType {
+	if len(p.Types) == 0 {
+		return []corev1.NodeAddressType{corev1.NodeExternalIP, corev1.NodeInternalIP}
+	}
+	return p.Types
+}
+
+// families returns preference order of IP families
+func (p NodeAddressPolicy) families() []corev1.IPFamily {
+	if len(p.Families) == 0 {
+		return []corev1.IPFamily{corev1.IPv4Protocol, corev1.IPv6Protocol}
+	}
+	return p.Families
+}
+
+// selectFamilies returns families used as upstreams out of available ones:
+// the most preferred one or all of them in dual stack mode
+func (p NodeAddressPolicy) selectFamilies(available func(corev1.IPFamily) bool) []corev1.IPFamily {
+	var result []corev1.IPFamily
+	for _, family := range p.families() {
+		if !available(family) {
+			continue
+		}
+		result = append(result, family)
+		if !p.DualStack {
+			break
+		}
+	}
+	return result
+}
+
+// ipFamily returns family of IP address, empty for invalid address
+func ipFamily(address string) corev1.IPFamily {
+	ip, err := netip.ParseAddr(address)
+	switch {
+	case err != nil:
+		return ""
+	case ip.Is4() || ip.Is4In6():
+		return corev1.IPv4Protocol
+	default:
+		return corev1.IPv6Protocol
+	}
+}
+
+// nodeDrain is a drain of cordoned or deleted node
+type nodeDrain struct {
+	until time.Time
+	// reported contains gateways notified about drain end
+	reported map[string]bool
+	// ips contains upstream IPs of node, they are used as draining upstreams after node is deleted
+	ips []string
+}
+
+// isNodeEligible returns true if node can be used as load balancer upstream.
+// Like kubernetes service controller it skips not ready, cordoned, excluded from load balancers
+// and nodes being deleted by cluster autoscaler. Node should also match selector if it's set.
+// Control plane nodes are used unless they are excluded from load balancers by label.
+func isNodeEligible(node *corev1.Node, selector labels.Selector) bool {
+	return isNodeUpstream(node, selector) && !isNodeDraining(node)
+}
+
+// isNodeUpstream returns true if node is ready, not excluded from load balancers and matches selector.
+// Such node is an upstream until it's drained.
+func isNodeUpstream(node *corev1.Node, selector labels.Selector) bool {
+	if !isNodeReady(node) {
+		return false
+	}
+	if _, ok := node.Labels[config.NODE_EXCLUDE_LB_LABEL]; ok {
+		return false
+	}
+	return selector == nil || selector.Matches(labels.Set(node.Labels))
+}
+
+// isNodeDraining returns true if node is cordoned or being deleted
+func isNodeDraining(node *corev1.Node) bool {
+	if node.Spec.Unschedulable || node.DeletionTimestamp != nil {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == config.NODE_TO_BE_DELETED_TAINT {
+			return true
+		}
+	}
+	return false
+}
+
+// nodeAddresses returns node IPs used as upstreams according to policy
+func nodeAddresses(node *corev1.Node, policy NodeAddressPolicy) []string {
+	byFamily := make(map[corev1.IPFamily]string)
+	for _, addrType := range policy.addressTypes() {
+		for _, addr := range node.Status.Addresses {
+			family := ipFamily(addr.Address)
+			if addr.Type != addrType || family == "" || byFamily[family] != "" {
+				continue
+			}
+			byFamily[family] = addr.Address
+		}
+	}
+	var result []string
+	for _, family := range policy.selectFamilies(func(f corev1.IPFamily) bool { return byFamily[f] != "" }) {
+		result = append(result, byFamily[family])
+	}
+	return result
+}
+
+// getNodesIpList return ips of nodes eligible as upstreams.
+// Cordoned or deleted node stays draining upstream during NodeDrainGracePeriod.
+// Drain start is taken from node annotation set by NodeDrainReconciler, so drain survives controller restart,
+// nodes are never written here.
+func (r *GatewayReconciler) getNodesIpList(ctx context.Context) (*nodeIPs, error) {
+	var nodes corev1.NodeList
+	if err := r.List(ctx, &nodes); err != nil {
+		return nil, err
+	}
+
+	r.drainMu.Lock()
+	defer r.drainMu.Unlock()
+	if r.drains == nil {
+		r.drains = make(map[string]*nodeDrain)
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	now := r.now()
+
+	result := &nodeIPs{drainUntil: make(map[string]time.Time), byName: make(map[string][]string)}
+	seen := make(map[string]bool)
+	listed := make(map[string]bool, len(nodes.Items))
+	for _, node := range nodes.Items {
+		listed[node.Name] = true
+		ips := nodeAddresses(&node, r.NodeAddresses)
+		if len(ips) == 0 || !isNodeUpstream(&node, r.NodeSelector) {
+			continue
+		}
+		if !isNodeDraining(&node) {
+			result.active = append(result.active, ips...)
+			result.byName[node.Name] = ips
+			continue
+		}
+		seen[node.Name] = true
+		drain, ok := r.drains[node.Name]
+		if !ok {
+			drain = &nodeDrain{until: r.drainStart(&node, now).Add(r.NodeDrainGracePeriod), reported: make(map[string]bool)}
+			r.drains[node.Name] = drain
+		}
+		drain.ips = ips
+		if now.Before(drain.until) {
+			result.draining = append(result.draining, ips...)
+			result.drainUntil[node.Name] = drain.until
+			result.byName[node.Name] = ips
+		} else {
+			result.drained = append(result.drained, node.Name)
+		}
+	}
+	for name, drain := range r.drains {
+		switch {
+		case seen[name]:
+		case listed[name]:
+			// drain of uncordoned node is over
+			delete(r.drains, name)
+		case now.Before(drain.until):
+			// deleted node keeps draining
+			result.draining = append(result.draining, drain.ips...)
+			result.drainUntil[name] = drain.until
+			result.byName[name] = drain.ips
+		case now.Before(drain.until.Add(r.NodeDrainGracePeriod)):
+			// deleted node is kept for a while to report drain end
+			result.drained = append(result.drained, name)
+		default:
+			delete(r.drains, name)
+		}
+	}
+	sort.Strings(result.draining)
+	sort.Strings(result.drained)
+	return result, nil
+}
+
+// drainStart returns drain start of node from its annotation, drain without annotation starts now
+func (r *GatewayReconciler) drainStart(node *corev1.Node, now time.Time) time.Time {
+	if start, err := time.Parse(time.RFC3339, node.Annotations[r.Keys.DrainStartedAnnotation()]); err == nil {
+		return start
+	}
+	return now.Truncate(time.Second)
+}
+
+// nodeDeleted starts drain of deleted upstream node, drain started before deletion is continued.
+// Deleted node isn't listed anymore, so its IPs are remembered until drain ends.
+func (r *GatewayReconciler) nodeDeleted(node *corev1.Node) {
+	if r.NodeDrainGracePeriod == 0 || !isNodeUpstream(node, r.NodeSelector) {
+		return
+	}
+	ips := nodeAddresses(node, r.NodeAddresses)
+	if len(ips) == 0 {
+		return
+	}
+
+	r.drainMu.Lock()
+	defer r.drainMu.Unlock()
+	if r.drains == nil {
+		r.drains = make(map[string]*nodeDrain)
+	}
+	if r.now == nil {
+		r.now = time.Now
+	}
+	drain, ok := r.drains[node.Name]
+	if !ok {
+		drain = &nodeDrain{until: r.drainStart(node, r.now()).Add(r.NodeDrainGracePeriod), reported: make(map[string]bool)}
+		r.drains[node.Name] = drain
+	}
+	drain.ips = ips
+}
+
+// nodeEventHandler enqueues gateways on node changes and starts drain of deleted nodes
+type nodeEventHandler struct {
+	handler.EventHandler
+	r *GatewayReconciler
+}
+
+// Delete implements handler.EventHandler
+func (h nodeEventHandler) Delete(ctx context.Context, e event.DeleteEvent, q workqueue.TypedRateLimitingInterface[reconcile.Request]) {
+	if node, ok := e.Object.(*corev1.Node); ok {
+		h.r.nodeDeleted(node)
+	}
+	h.EventHandler.Delete(ctx, e, q)
+}
+
+// unreportedDrains returns drained nodes which gateway isn't notified about and marks them reported
+func (r *GatewayReconciler) unreportedDrains(gwKey string, drained []string) []string {
+	r.drainMu.Lock()
+	defer r.drainMu.Unlock()
+	var result []string
+	for _, name := range drained {
+		drain, ok := r.drains[name]
+		if !ok || drain.reported[gwKey] {
+			continue
+		}
+		drain.reported[gwKey] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// drainRequeueAfter returns time until the nearest drain end if it's sooner than interval
+func (r *GatewayReconciler) drainRequeueAfter(drainUntil map[string]time.Time, interval time.Duration) time.Duration {
+	for _, until := range drainUntil {
+		left := until.Sub(r.now())
+		if left < time.Second {
+			left = time.Second
+		}
+		if interval == 0 || left < interval {
+			interval = left
+		}
+	}
+	return interval
+}
+
+// reportNodeDrains emits events about draining nodes and nodes removed from gateway upstreams after drain
+func (r *GatewayReconciler) reportNodeDrains(gw *gatewayv1.Gateway, gwInfo *types.GatewayInfo) {
+	names := make([]string, 0, len(gwInfo.NodeDrains))
+	for name := range gwInfo.NodeDrains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.Recorder.Eventf(gw, corev1.EventTypeNormal, "NodeDraining",
+			"Node %s is draining, it will be removed from upstreams at %s", name, gwInfo.NodeDrains[name].Format(time.RFC3339))
+	}
+	for _, name := range r.unreportedDrains(string(gw.UID), gwInfo.DrainedNodes) {
+		r.Recorder.Eventf(gw, corev1.EventTypeNormal, "NodeDrained", "Node %s is drained and removed from upstreams", name)
+	}
+}
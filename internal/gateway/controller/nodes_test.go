@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func newReadyNode(name, ip string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{}},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func Test_isNodeEligible(t *testing.T) {
+	selector, err := labels.Parse("pool=edge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		mutate   func(n *corev1.Node)
+		selector labels.Selector
+		expected bool
+	}{
+		{name: "ready", mutate: func(n *corev1.Node) {}, expected: true},
+		{name: "not ready", mutate: func(n *corev1.Node) { n.Status.Conditions[0].Status = corev1.ConditionFalse }},
+		{name: "no ready condition", mutate: func(n *corev1.Node) { n.Status.Conditions = nil }},
+		{name: "cordoned", mutate: func(n *corev1.Node) { n.Spec.Unschedulable = true }},
+		{name: "excluded", mutate: func(n *corev1.Node) { n.Labels[config.NODE_EXCLUDE_LB_LABEL] = "" }},
+		{name: "control plane", mutate: func(n *corev1.Node) { n.Labels["node-role.kubernetes.io/control-plane"] = "" }, expected: true},
+		{name: "deleted by autoscaler", mutate: func(n *corev1.Node) {
+			n.Spec.Taints = []corev1.Taint{{Key: config.NODE_TO_BE_DELETED_TAINT, Effect: corev1.TaintEffectNoSchedule}}
+		}},
+		{name: "other taint", mutate: func(n *corev1.Node) {
+			n.Spec.Taints = []corev1.Taint{{Key: "dedicated", Effect: corev1.TaintEffectNoSchedule}}
+		}, expected: true},
+		{name: "selector matches", mutate: func(n *corev1.Node) { n.Labels["pool"] = "edge" }, selector: selector, expected: true},
+		{name: "selector doesn't match", mutate: func(n *corev1.Node) { n.Labels["pool"] = "core" }, selector: selector},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			node := newReadyNode("n1", "10.0.0.1")
+			tt.mutate(node)
+			g.Expect(isNodeEligible(node, tt.selector)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func Test_getNodesIpList(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+
+	ready := newReadyNode("ready", "10.0.0.1")
+	edge := newReadyNode("edge", "10.0.0.2")
+	edge.Labels["pool"] = "edge"
+	excluded := newReadyNode("excluded", "10.0.0.4")
+	excluded.Labels[config.NODE_EXCLUDE_LB_LABEL] = ""
+
+	r := &GatewayReconciler{
+		Client: fake.NewClientBuilder().WithScheme(s).WithObjects(ready, edge, excluded).Build(),
+	}
+	nodes, err := r.getNodesIpList(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.active).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+	g.Expect(nodes.draining).To(BeEmpty())
+
+	r.NodeSelector, err = labels.Parse("pool=edge")
+	g.Expect(err).To(BeNil())
+	nodes, err = r.getNodesIpList(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.active).To(ConsistOf("10.0.0.2"))
+}
+
+func Test_getNodesIpList_Drain(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+	ctx := context.Background()
+
+	ready := newReadyNode("ready", "10.0.0.1")
+	cordoned := newReadyNode("cordoned", "10.0.0.2")
+	cordoned.Spec.Unschedulable = true
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(ready, cordoned).Build()
+
+	now := time.Now().Truncate(time.Second)
+	r := &GatewayReconciler{
+		Client:               c,
+		NodeDrainGracePeriod: time.Minute,
+		now:                  func() time.Time { return now },
+	}
+
+	// drain starts
+	nodes, err := r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.active).To(ConsistOf("10.0.0.1"))
+	g.Expect(nodes.draining).To(ConsistOf("10.0.0.2"))
+	g.Expect(nodes.drainUntil).To(HaveKeyWithValue("cordoned", now.Add(time.Minute)))
+	g.Expect(r.drainRequeueAfter(nodes.drainUntil, 10*time.Minute)).To(Equal(time.Minute))
+
+	// node isn't written, drain start is stored by NodeDrainReconciler
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(cordoned), cordoned)).To(Succeed())
+	g.Expect(cordoned.Annotations).NotTo(HaveKey(config.NODE_DRAIN_STARTED_ANNOTATION))
+	cordoned.Annotations = map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: now.Format(time.RFC3339)}
+	g.Expect(c.Update(ctx, cordoned)).To(Succeed())
+
+	// drain continues after restart
+	now = now.Add(30 * time.Second)
+	r = &GatewayReconciler{
+		Client:               c,
+		NodeDrainGracePeriod: time.Minute,
+		now:                  func() time.Time { return now },
+	}
+	nodes, err = r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.draining).To(ConsistOf("10.0.0.2"))
+	g.Expect(r.drainRequeueAfter(nodes.drainUntil, 10*time.Minute)).To(Equal(30 * time.Second))
+
+	// drain ends, node is removed
+	now = now.Add(time.Minute)
+	nodes, err = r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.active).To(ConsistOf("10.0.0.1"))
+	g.Expect(nodes.draining).To(BeEmpty())
+	g.Expect(nodes.drained).To(Equal([]string{"cordoned"}))
+	g.Expect(r.unreportedDrains("gw", nodes.drained)).To(Equal([]string{"cordoned"}))
+	g.Expect(r.unreportedDrains("gw", nodes.drained)).To(BeEmpty())
+	g.Expect(r.drainRequeueAfter(nodes.drainUntil, 10*time.Minute)).To(Equal(10 * time.Minute))
+
+	// uncordoned node is active again
+	g.Expect(c.Get(ctx, client.ObjectKeyFromObject(cordoned), cordoned)).To(Succeed())
+	cordoned.Spec.Unschedulable = false
+	g.Expect(c.Update(ctx, cordoned)).To(Succeed())
+	nodes, err = r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.active).To(ConsistOf("10.0.0.1", "10.0.0.2"))
+	g.Expect(r.drains).To(BeEmpty())
+}
+
+func Test_getNodesIpList_DeletedNode(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+	ctx := context.Background()
+
+	ready := newReadyNode("ready", "10.0.0.1")
+	deleted := newReadyNode("deleted", "10.0.0.2")
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(ready).Build()
+
+	now := time.Now().Truncate(time.Second)
+	r := &GatewayReconciler{
+		Client:               c,
+		NodeDrainGracePeriod: time.Minute,
+		now:                  func() time.Time { return now },
+	}
+
+	// node deleted at once is drained too
+	r.nodeDeleted(deleted)
+	nodes, err := r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.active).To(ConsistOf("10.0.0.1"))
+	g.Expect(nodes.draining).To(ConsistOf("10.0.0.2"))
+	g.Expect(nodes.drainUntil).To(HaveKeyWithValue("deleted", now.Add(time.Minute)))
+
+	// drain ends and is reported
+	now = now.Add(time.Minute)
+	nodes, err = r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.draining).To(BeEmpty())
+	g.Expect(nodes.drained).To(Equal([]string{"deleted"}))
+
+	// deleted node is forgotten
+	now = now.Add(time.Minute)
+	nodes, err = r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.drained).To(BeEmpty())
+	g.Expect(r.drains).To(BeEmpty())
+
+	// drain started before deletion is continued
+	deleted.Annotations = map[string]string{config.NODE_DRAIN_STARTED_ANNOTATION: now.Add(-30 * time.Second).Format(time.RFC3339)}
+	r.nodeDeleted(deleted)
+	nodes, err = r.getNodesIpList(ctx)
+	g.Expect(err).To(BeNil())
+	g.Expect(nodes.drainUntil["deleted"]).To(BeTemporally("==", now.Add(30*time.Second)))
+}
+
+func Test_reportNodeDrains(t *testing.T) {
+	g := NewWithT(t)
+	recorder := record.NewFakeRecorder(10)
+	until := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := &GatewayReconciler{
+		Recorder: recorder,
+		drains:   map[string]*nodeDrain{"n2": {until: until, reported: map[string]bool{}}},
+	}
+	gw := &gatewayv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: testGw, Namespace: testGwNs, UID: "gw-uid"}}
+	gwInfo := &types.GatewayInfo{
+		NodeDrains:   map[string]time.Time{"n1": until},
+		DrainedNodes: []string{"n2"},
+	}
+
+	r.reportNodeDrains(gw, gwInfo)
+	g.Expect(recorder.Events).To(HaveLen(2))
+	g.Expect(<-recorder.Events).To(Equal("Normal NodeDraining Node n1 is draining, it will be removed from upstreams at 2025-01-01T00:00:00Z"))
+	g.Expect(<-recorder.Events).To(Equal("Normal NodeDrained Node n2 is drained and removed from upstreams"))
+
+	// drain end is reported once
+	gwInfo.NodeDrains = nil
+	r.reportNodeDrains(gw, gwInfo)
+	g.Expect(recorder.Events).To(BeEmpty())
+}
+
+func Test_NewNodeAddressPolicy(t *testing.T) {
+	g := NewWithT(t)
+
+	policy, err := NewNodeAddressPolicy([]string{"InternalIP"}, []string{"IPv6", "IPv4"}, true)
+	g.Expect(err).To(BeNil())
+	g.Expect(policy).To(Equal(NodeAddressPolicy{
+		Types:     []corev1.NodeAddressType{corev1.NodeInternalIP},
+		Families:  []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+		DualStack: true,
+	}))
+
+	_, err = NewNodeAddressPolicy([]string{"Hostname"}, nil, false)
+	g.Expect(err).To(HaveOccurred())
+	_, err = NewNodeAddressPolicy(nil, []string{"ipv4"}, false)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_nodeAddresses(t *testing.T) {
+	node := &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+		{Type: corev1.NodeHostName, Address: "n1"},
+		{Type: corev1.NodeInternalIP, Address: "fd00::10"},
+		{Type: corev1.NodeInternalIP, Address: "10.0.0.10"},
+		{Type: corev1.NodeExternalIP, Address: "2001:db8::10"},
+	}}}
+	tests := []struct {
+		name     string
+		policy   NodeAddressPolicy
+		expected []string
+	}{
+		{name: "default prefers IPv4", expected: []string{"10.0.0.10"}},
+		{
+			name:     "IPv6 external",
+			policy:   NodeAddressPolicy{Families: []corev1.IPFamily{corev1.IPv6Protocol}},
+			expected: []string{"2001:db8::10"},
+		},
+		{
+			name: "IPv6 internal",
+			policy: NodeAddressPolicy{
+				Types:    []corev1.NodeAddressType{corev1.NodeInternalIP},
+				Families: []corev1.IPFamily{corev1.IPv6Protocol, corev1.IPv4Protocol},
+			},
+			expected: []string{"fd00::10"},
+		},
+		{
+			name:     "external only falls back to IPv6",
+			policy:   NodeAddressPolicy{Types: []corev1.NodeAddressType{corev1.NodeExternalIP}},
+			expected: []string{"2001:db8::10"},
+		},
+		{name: "dual stack", policy: NodeAddressPolicy{DualStack: true}, expected: []string{"10.0.0.10", "2001:db8::10"}},
+		{
+			name:     "dual stack limited by families",
+			policy:   NodeAddressPolicy{Families: []corev1.IPFamily{corev1.IPv4Protocol}, DualStack: true},
+			expected: []string{"10.0.0.10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			g.Expect(nodeAddresses(node, tt.policy)).To(Equal(tt.expected))
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// InvalidParametersError is returned when parameters referenced by GatewayClass are missing or not valid.
+type InvalidParametersError struct {
+	Reason string
+}
+
+func (e *InvalidParametersError) Error() string {
+	return fmt.Sprintf("invalid GatewayClass parameters: %s", e.Reason)
+}
+
+// classConfigInstalled returns true if ServerscomGatewayClassConfig CRD is installed in cluster.
+// Watch of kind unknown to API server fails controller start, so the kind is watched only if CRD is installed.
+func classConfigInstalled(mapper meta.RESTMapper) (bool, error) {
+	gk := schema.GroupKind{Group: v1alpha1.GroupVersion.Group, Kind: v1alpha1.ServerscomGatewayClassConfigKind}
+	_, err := mapper.RESTMapping(gk, v1alpha1.GroupVersion.Version)
+	if meta.IsNoMatchError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to discover %s: %w", gk, err)
+	}
+	return true, nil
+}
+
+// resolveClassParams returns LB settings from ServerscomGatewayClassConfig referenced by GatewayClass.
+// Class without parametersRef gets empty params.
+func resolveClassParams(ctx context.Context, c client.Reader, gc *gatewayv1.GatewayClass) (types.LBParams, error) {
+	ref := gc.Spec.ParametersRef
+	if ref == nil {
+		return types.LBParams{}, nil
+	}
+	if string(ref.Group) != v1alpha1.GroupVersion.Group || string(ref.Kind) != v1alpha1.ServerscomGatewayClassConfigKind {
+		return types.LBParams{}, &InvalidParametersError{
+			Reason: fmt.Sprintf("unsupported parametersRef %s/%s, expected %s/%s",
+				ref.Group, ref.Kind, v1alpha1.GroupVersion.Group, v1alpha1.ServerscomGatewayClassConfigKind),
+		}
+	}
+	if ref.Namespace != nil {
+		return types.LBParams{}, &InvalidParametersError{
+			Reason: fmt.Sprintf("%s is cluster scoped, parametersRef namespace must be empty", ref.Kind),
+		}
+	}
+
+	var cfg v1alpha1.ServerscomGatewayClassConfig
+	if err := c.Get(ctx, client.ObjectKey{Name: ref.Name}, &cfg); err != nil {
+		if apierrors.IsNotFound(err) {
+			return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("%s %q not found", ref.Kind, ref.Name)}
+		}
+		if meta.IsNoMatchError(err) {
+			return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("%s CRD is not installed", ref.Kind)}
+		}
+		return types.LBParams{}, err
+	}
+	if cfg.Spec.LocationID != nil && *cfg.Spec.LocationID <= 0 {
+		return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("%s %q: locationID must be positive", ref.Kind, ref.Name)}
+	}
+
+	params := types.LBParams{
+		ClusterID:         cfg.Spec.ClusterID,
+		StoreLogs:         cfg.Spec.StoreLogs,
+		StoreLogsRegionID: cfg.Spec.StoreLogsRegionID,
+		Geoip:             cfg.Spec.Geoip,
+	}
+	if cfg.Spec.LocationID != nil {
+		params.LocationID = *cfg.Spec.LocationID
+	}
+	if cfg.Spec.UpstreamMode != nil {
+		params.UpstreamMode = *cfg.Spec.UpstreamMode
+	}
+	if err := validateParams(params); err != nil {
+		return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("%s %q: %s", ref.Kind, ref.Name, err)}
+	}
+	return params, nil
+}
+
+// resolveGatewayParams returns LB settings of the gateway.
+// Class params are overridden by ConfigMap referenced by infrastructure parametersRef,
+// which in turn are overridden by infrastructure annotations.
+// ConfigMap keys are named after ServerscomGatewayClassConfig spec fields.
+func resolveGatewayParams(ctx context.Context, c client.Reader, gw *gatewayv1.Gateway, classParams types.LBParams) (types.LBParams, error) {
+	infra := gw.Spec.Infrastructure
+	if infra == nil {
+		return classParams, nil
+	}
+	params := classParams
+
+	if ref := infra.ParametersRef; ref != nil {
+		if ref.Group != "" || ref.Kind != "ConfigMap" {
+			return types.LBParams{}, &InvalidParametersError{
+				Reason: fmt.Sprintf("unsupported infrastructure parametersRef %s/%s, expected ConfigMap", ref.Group, ref.Kind),
+			}
+		}
+		var cm corev1.ConfigMap
+		if err := c.Get(ctx, client.ObjectKey{Namespace: gw.Namespace, Name: ref.Name}, &cm); err != nil {
+			if apierrors.IsNotFound(err) {
+				return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("ConfigMap %q not found", ref.Name)}
+			}
+			return types.LBParams{}, err
+		}
+		for key := range cm.Data {
+			if !slices.Contains(paramKeys, key) {
+				return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("ConfigMap %q: unknown parameter %q", ref.Name, key)}
+			}
+		}
+		var err error
+		if params, err = overrideParams(params, cm.Data); err != nil {
+			return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("ConfigMap %q: %s", ref.Name, err)}
+		}
+	}
+
+	values := make(map[string]string)
+	for annotation, key := range paramAnnotations {
+		if v, ok := infra.Annotations[gatewayv1.AnnotationKey(annotation)]; ok {
+			values[key] = string(v)
+		}
+	}
+	var err error
+	if params, err = overrideParams(params, values); err != nil {
+		return types.LBParams{}, &InvalidParametersError{Reason: fmt.Sprintf("infrastructure annotations: %s", err)}
+	}
+
+	if err := validateParams(params); err != nil {
+		return types.LBParams{}, &InvalidParametersError{Reason: err.Error()}
+	}
+	return params, nil
+}
+
+// paramKeys contains names of LB params which can be set per gateway
+var paramKeys = []string{"locationID", "clusterID", "storeLogs", "storeLogsRegionID", "geoip"}
+
+// paramAnnotations maps infrastructure annotations to LB params
+var paramAnnotations = map[string]string{
+	config.LOCATION_ID_KEY:          "locationID",
+	config.LB_CLUSTER_ID_KEY:        "clusterID",
+	config.STORE_LOGS_KEY:           "storeLogs",
+	config.STORE_LOGS_REGION_ID_KEY: "storeLogsRegionID",
+	config.GEOIP_KEY:                "geoip",
+}
+
+// overrideParams returns params with fields replaced by given values
+func overrideParams(params types.LBParams, values map[string]string) (types.LBParams, error) {
+	for key, value := range values {
+		switch key {
+		case "locationID":
+			v, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || v <= 0 {
+				return params, fmt.Errorf("locationID must be positive integer, got %q", value)
+			}
+			params.LocationID = v
+		case "clusterID":
+			params.ClusterID = &value
+		case "storeLogs":
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return params, fmt.Errorf("storeLogs must be boolean, got %q", value)
+			}
+			params.StoreLogs = &v
+		case "storeLogsRegionID":
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return params, fmt.Errorf("storeLogsRegionID must be integer, got %q", value)
+			}
+			params.StoreLogsRegionID = &v
+		case "geoip":
+			v, err := strconv.ParseBool(value)
+			if err != nil {
+				return params, fmt.Errorf("geoip must be boolean, got %q", value)
+			}
+			params.Geoip = &v
+		}
+	}
+	return params, nil
+}
+
+// validateParams checks values which can't be validated by CRD schema
+func validateParams(params types.LBParams) error {
+	if params.ClusterID != nil && *params.ClusterID == "" {
+		return fmt.Errorf("clusterID can't be empty")
+	}
+	if params.StoreLogsRegionID != nil {
+		if *params.StoreLogsRegionID <= 0 {
+			return fmt.Errorf("storeLogsRegionID must be positive")
+		}
+		if params.StoreLogs == nil || !*params.StoreLogs {
+			return fmt.Errorf("storeLogsRegionID requires storeLogs enabled")
+		}
+	}
+	return nil
+}
+
+// infrastructureLabels returns infrastructure labels of the gateway to copy onto LB.
+// Labels reserved by controller keys are skipped, they mark ownership and state of LB.
+func infrastructureLabels(gw *gatewayv1.Gateway, keys config.Keys) map[string]string {
+	if gw.Spec.Infrastructure == nil || len(gw.Spec.Infrastructure.Labels) == 0 {
+		return nil
+	}
+	labels := make(map[string]string)
+	for k, v := range gw.Spec.Infrastructure.Labels {
+		if keys.Reserved(string(k)) {
+			continue
+		}
+		labels[string(k)] = string(v)
+	}
+	return labels
+}
+
+// gatewayClassesForParams returns names of GatewayClasses referencing given ServerscomGatewayClassConfig
+func gatewayClassesForParams(ctx context.Context, c client.Reader, name string) ([]string, error) {
+	var classes gatewayv1.GatewayClassList
+	if err := c.List(ctx, &classes); err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, gc := range classes.Items {
+		ref := gc.Spec.ParametersRef
+		if ref != nil && string(ref.Group) == v1alpha1.GroupVersion.Group &&
+			string(ref.Kind) == v1alpha1.ServerscomGatewayClassConfigKind && ref.Name == name {
+			res = append(res, gc.Name)
+		}
+	}
+	return res, nil
+}
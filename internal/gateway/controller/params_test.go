@@ -0,0 +1,288 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/types"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func paramsRef(name string) *gatewayv1.ParametersReference {
+	return &gatewayv1.ParametersReference{
+		Group: gatewayv1.Group(v1alpha1.GroupVersion.Group),
+		Kind:  gatewayv1.Kind(v1alpha1.ServerscomGatewayClassConfigKind),
+		Name:  name,
+	}
+}
+
+func Test_resolveClassParams(t *testing.T) {
+	s := setupScheme(t)
+	locationID := int64(5)
+	clusterID := "lb-cluster"
+	regionID := 2
+	ns := gatewayv1.Namespace("default")
+	valid := &v1alpha1.ServerscomGatewayClassConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "valid"},
+		Spec: v1alpha1.ServerscomGatewayClassConfigSpec{
+			LocationID:        &locationID,
+			ClusterID:         &clusterID,
+			StoreLogs:         utils.BoolPtr(true),
+			StoreLogsRegionID: &regionID,
+			Geoip:             utils.BoolPtr(false),
+		},
+	}
+	invalid := &v1alpha1.ServerscomGatewayClassConfig{
+		ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+		Spec: v1alpha1.ServerscomGatewayClassConfigSpec{
+			StoreLogsRegionID: &regionID,
+		},
+	}
+	cli := fake.NewClientBuilder().WithScheme(s).WithObjects(valid, invalid).Build()
+
+	tests := []struct {
+		name     string
+		ref      *gatewayv1.ParametersReference
+		expected types.LBParams
+		wantErr  bool
+	}{
+		{name: "no parametersRef"},
+		{
+			name: "valid config",
+			ref:  paramsRef("valid"),
+			expected: types.LBParams{
+				LocationID:        5,
+				ClusterID:         &clusterID,
+				StoreLogs:         utils.BoolPtr(true),
+				StoreLogsRegionID: &regionID,
+				Geoip:             utils.BoolPtr(false),
+			},
+		},
+		{name: "config not found", ref: paramsRef("missing"), wantErr: true},
+		{name: "invalid config", ref: paramsRef("invalid"), wantErr: true},
+		{
+			name: "unsupported kind",
+			ref: &gatewayv1.ParametersReference{
+				Group: "",
+				Kind:  "ConfigMap",
+				Name:  "valid",
+			},
+			wantErr: true,
+		},
+		{
+			name: "namespace set",
+			ref: func() *gatewayv1.ParametersReference {
+				ref := paramsRef("valid")
+				ref.Namespace = &ns
+				return ref
+			}(),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			gc := &gatewayv1.GatewayClass{Spec: gatewayv1.GatewayClassSpec{ParametersRef: tt.ref}}
+			params, err := resolveClassParams(context.Background(), cli, gc)
+			if tt.wantErr {
+				var paramsErr *InvalidParametersError
+				g.Expect(err).To(BeAssignableToTypeOf(paramsErr))
+				return
+			}
+			g.Expect(err).To(BeNil())
+			g.Expect(params).To(Equal(tt.expected))
+		})
+	}
+
+	t.Run("crd not installed", func(t *testing.T) {
+		g := NewWithT(t)
+		cli := fake.NewClientBuilder().WithScheme(s).WithInterceptorFuncs(interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return &meta.NoKindMatchError{GroupKind: schema.GroupKind{Group: v1alpha1.GroupVersion.Group, Kind: v1alpha1.ServerscomGatewayClassConfigKind}}
+			},
+		}).Build()
+		gc := &gatewayv1.GatewayClass{Spec: gatewayv1.GatewayClassSpec{ParametersRef: paramsRef("valid")}}
+		_, err := resolveClassParams(context.Background(), cli, gc)
+		var paramsErr *InvalidParametersError
+		g.Expect(errors.As(err, &paramsErr)).To(BeTrue())
+		g.Expect(err.Error()).To(ContainSubstring("CRD is not installed"))
+	})
+}
+
+func Test_classConfigInstalled(t *testing.T) {
+	g := NewWithT(t)
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	installed, err := classConfigInstalled(mapper)
+	g.Expect(err).To(BeNil())
+	g.Expect(installed).To(BeFalse())
+
+	mapper.Add(v1alpha1.GroupVersion.WithKind(v1alpha1.ServerscomGatewayClassConfigKind), meta.RESTScopeRoot)
+	installed, err = classConfigInstalled(mapper)
+	g.Expect(err).To(BeNil())
+	g.Expect(installed).To(BeTrue())
+}
+
+func Test_gatewayClassesForParams(t *testing.T) {
+	g := NewWithT(t)
+	s := setupScheme(t)
+	gc1 := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc1"},
+		Spec:       gatewayv1.GatewayClassSpec{ParametersRef: paramsRef("cfg")},
+	}
+	gc2 := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc2"},
+		Spec:       gatewayv1.GatewayClassSpec{ParametersRef: paramsRef("other")},
+	}
+	gc3 := &gatewayv1.GatewayClass{ObjectMeta: metav1.ObjectMeta{Name: "gc3"}}
+	cli := fake.NewClientBuilder().WithScheme(s).WithObjects(gc1, gc2, gc3).Build()
+
+	names, err := gatewayClassesForParams(context.Background(), cli, "cfg")
+	g.Expect(err).To(BeNil())
+	g.Expect(names).To(Equal([]string{"gc1"}))
+}
+
+func Test_resolveGatewayParams(t *testing.T) {
+	s := setupScheme(t)
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "lb-params", Namespace: testGwNs},
+		Data:       map[string]string{"locationID": "3", "storeLogs": "true", "storeLogsRegionID": "4"},
+	}
+	badCM := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "bad", Namespace: testGwNs},
+		Data:       map[string]string{"location": "3"},
+	}
+	cli := fake.NewClientBuilder().WithScheme(s).WithObjects(cm, badCM).Build()
+
+	classClusterID := "class-cluster"
+	gwClusterID := "gw-cluster"
+	classParams := types.LBParams{LocationID: 1, ClusterID: &classClusterID, Geoip: utils.BoolPtr(true)}
+	regionID := 4
+
+	tests := []struct {
+		name     string
+		infra    *gatewayv1.GatewayInfrastructure
+		expected types.LBParams
+		wantErr  bool
+	}{
+		{name: "no infrastructure", expected: classParams},
+		{
+			name:  "configmap overrides class",
+			infra: &gatewayv1.GatewayInfrastructure{ParametersRef: &gatewayv1.LocalParametersReference{Kind: "ConfigMap", Name: "lb-params"}},
+			expected: types.LBParams{
+				LocationID:        3,
+				ClusterID:         &classClusterID,
+				StoreLogs:         utils.BoolPtr(true),
+				StoreLogsRegionID: &regionID,
+				Geoip:             utils.BoolPtr(true),
+			},
+		},
+		{
+			name: "annotations override configmap",
+			infra: &gatewayv1.GatewayInfrastructure{
+				ParametersRef: &gatewayv1.LocalParametersReference{Kind: "ConfigMap", Name: "lb-params"},
+				Annotations: map[gatewayv1.AnnotationKey]gatewayv1.AnnotationValue{
+					config.LOCATION_ID_KEY:   "5",
+					config.LB_CLUSTER_ID_KEY: "gw-cluster",
+					config.GEOIP_KEY:         "false",
+					"other":                  "value",
+				},
+			},
+			expected: types.LBParams{
+				LocationID:        5,
+				ClusterID:         &gwClusterID,
+				StoreLogs:         utils.BoolPtr(true),
+				StoreLogsRegionID: &regionID,
+				Geoip:             utils.BoolPtr(false),
+			},
+		},
+		{
+			name:    "configmap not found",
+			infra:   &gatewayv1.GatewayInfrastructure{ParametersRef: &gatewayv1.LocalParametersReference{Kind: "ConfigMap", Name: "missing"}},
+			wantErr: true,
+		},
+		{
+			name:    "unknown configmap key",
+			infra:   &gatewayv1.GatewayInfrastructure{ParametersRef: &gatewayv1.LocalParametersReference{Kind: "ConfigMap", Name: "bad"}},
+			wantErr: true,
+		},
+		{
+			name: "unsupported kind",
+			infra: &gatewayv1.GatewayInfrastructure{ParametersRef: &gatewayv1.LocalParametersReference{
+				Group: gatewayv1.Group(v1alpha1.GroupVersion.Group),
+				Kind:  gatewayv1.Kind(v1alpha1.ServerscomGatewayClassConfigKind),
+				Name:  "cfg",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "malformed annotation",
+			infra: &gatewayv1.GatewayInfrastructure{Annotations: map[gatewayv1.AnnotationKey]gatewayv1.AnnotationValue{
+				config.STORE_LOGS_KEY: "yes please",
+			}},
+			wantErr: true,
+		},
+		{
+			name: "region without logs",
+			infra: &gatewayv1.GatewayInfrastructure{Annotations: map[gatewayv1.AnnotationKey]gatewayv1.AnnotationValue{
+				config.STORE_LOGS_REGION_ID_KEY: "2",
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			gw := &gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: testGw, Namespace: testGwNs},
+				Spec:       gatewayv1.GatewaySpec{Infrastructure: tt.infra},
+			}
+			params, err := resolveGatewayParams(context.Background(), cli, gw, classParams)
+			if tt.wantErr {
+				var paramsErr *InvalidParametersError
+				g.Expect(err).To(BeAssignableToTypeOf(paramsErr))
+				return
+			}
+			g.Expect(err).To(BeNil())
+			g.Expect(params).To(Equal(tt.expected))
+		})
+	}
+}
+
+func Test_infrastructureLabels(t *testing.T) {
+	g := NewWithT(t)
+	gw := &gatewayv1.Gateway{}
+	g.Expect(infrastructureLabels(gw, config.DefaultKeys())).To(BeNil())
+
+	gw.Spec.Infrastructure = &gatewayv1.GatewayInfrastructure{
+		Labels: map[gatewayv1.LabelKey]gatewayv1.LabelValue{
+			"team":             "payments",
+			config.GW_LABEL_ID: "spoofed",
+		},
+	}
+	g.Expect(infrastructureLabels(gw, config.DefaultKeys())).To(Equal(map[string]string{"team": "payments"}))
+
+	keys := config.Keys{GatewayLabel: "staging.example.com/gateway-id", OldGatewayLabel: "legacy/gw"}
+	gw.Spec.Infrastructure.Labels = map[gatewayv1.LabelKey]gatewayv1.LabelValue{
+		"team":                                  "payments",
+		"staging.example.com/gateway-id":        "spoofed",
+		"staging.example.com/gateway-id-cert-1": "true",
+		"legacy/gw":                             "spoofed",
+		"legacy/gw-replaces-lb-id":              "lb-1",
+		config.CLUSTER_LABEL_ID:                 "other",
+	}
+	g.Expect(infrastructureLabels(gw, keys)).To(Equal(map[string]string{"team": "payments"}))
+}
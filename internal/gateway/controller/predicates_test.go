@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_secretDataChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+	p := secretDataChangedPredicate()
+
+	oldSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", ResourceVersion: "1"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("old")},
+	}
+	sameData := oldSecret.DeepCopy()
+	sameData.ResourceVersion = "2"
+	sameData.Annotations = map[string]string{"a": "b"}
+	rotated := oldSecret.DeepCopy()
+	rotated.Data[corev1.TLSCertKey] = []byte("new")
+
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldSecret, ObjectNew: sameData})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: oldSecret, ObjectNew: rotated})).To(BeTrue())
+	g.Expect(p.Create(event.CreateEvent{Object: oldSecret})).To(BeTrue())
+	g.Expect(p.Delete(event.DeleteEvent{Object: oldSecret})).To(BeTrue())
+}
+
+func Test_nodeChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+	p := nodeChangedPredicate(nil)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.1"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	heartbeat := node.DeepCopy()
+	heartbeat.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+	notReady := node.DeepCopy()
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+	newAddr := node.DeepCopy()
+	newAddr.Status.Addresses[0].Address = "10.0.0.2"
+
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: heartbeat})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: notReady})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: newAddr})).To(BeTrue())
+	g.Expect(p.Create(event.CreateEvent{Object: node})).To(BeTrue())
+	g.Expect(p.Delete(event.DeleteEvent{Object: node})).To(BeTrue())
+
+	cordoned := node.DeepCopy()
+	cordoned.Spec.Unschedulable = true
+	excluded := node.DeepCopy()
+	excluded.Labels = map[string]string{config.NODE_EXCLUDE_LB_LABEL: ""}
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: cordoned})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: excluded})).To(BeTrue())
+
+	selector, err := labels.Parse("pool=edge")
+	g.Expect(err).To(BeNil())
+	p = nodeChangedPredicate(selector)
+	relabeled := node.DeepCopy()
+	relabeled.Labels = map[string]string{"pool": "edge"}
+	unrelated := node.DeepCopy()
+	unrelated.Labels = map[string]string{"team": "a"}
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: relabeled})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: node, ObjectNew: unrelated})).To(BeFalse())
+}
+
+func Test_namespaceLabelsChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+	p := namespaceLabelsChangedPredicate()
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ns", Labels: map[string]string{"team": "a"}}}
+	annotated := ns.DeepCopy()
+	annotated.Annotations = map[string]string{"a": "b"}
+	relabeled := ns.DeepCopy()
+	relabeled.Labels["team"] = "b"
+
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: ns, ObjectNew: annotated})).To(BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: ns, ObjectNew: relabeled})).To(BeTrue())
+	g.Expect(p.Create(event.CreateEvent{Object: ns})).To(BeFalse())
+}
+
+func Test_annotationChangedPredicate(t *testing.T) {
+	g := NewWithT(t)
+	p := annotationChangedPredicate("lb-id", "policy")
+
+	gw := &gatewayv1.Gateway{ObjectMeta: metav1.ObjectMeta{Name: "gw"}}
+	annotated := gw.DeepCopy()
+	annotated.Annotations = map[string]string{"lb-id": "lb1"}
+	other := gw.DeepCopy()
+	other.Annotations = map[string]string{"other": "value"}
+
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: gw, ObjectNew: annotated})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: annotated, ObjectNew: gw})).To(BeTrue())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: gw, ObjectNew: other})).To(BeFalse())
+
+	protected := annotated.DeepCopy()
+	protected.Annotations["policy"] = "Protect"
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: annotated, ObjectNew: protected})).To(BeTrue())
+}
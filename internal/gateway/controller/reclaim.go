@@ -0,0 +1,51 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ReclaimPolicy defines what happens with load balancer when gateway is deleted or no longer managed.
+type ReclaimPolicy string
+
+const (
+	// ReclaimPolicyDelete deletes load balancer, it's default policy
+	ReclaimPolicyDelete ReclaimPolicy = "Delete"
+	// ReclaimPolicyRetain keeps load balancer unmanaged, it can be adopted by another gateway
+	ReclaimPolicyRetain ReclaimPolicy = "Retain"
+	// ReclaimPolicyProtect blocks gateway deletion until deletion is explicitly allowed
+	ReclaimPolicyProtect ReclaimPolicy = "Protect"
+)
+
+// reclaimPolicy returns reclaim policy of the gateway.
+// Policy annotation of the gateway takes precedence over one of its GatewayClass.
+// Unknown policy is treated as Protect to not lose load balancer because of a typo, valid is false in this case.
+func (r *GatewayReconciler) reclaimPolicy(ctx context.Context, gw *gatewayv1.Gateway) (policy ReclaimPolicy, valid bool, err error) {
+	value, ok := gw.Annotations[config.RECLAIM_POLICY_KEY]
+	if !ok {
+		var gwClass gatewayv1.GatewayClass
+		if err := r.Get(ctx, client.ObjectKey{Name: string(gw.Spec.GatewayClassName)}, &gwClass); client.IgnoreNotFound(err) != nil {
+			return "", false, err
+		}
+		value, ok = gwClass.Annotations[config.RECLAIM_POLICY_KEY]
+	}
+	if !ok {
+		return ReclaimPolicyDelete, true, nil
+	}
+	switch p := ReclaimPolicy(value); p {
+	case ReclaimPolicyDelete, ReclaimPolicyRetain, ReclaimPolicyProtect:
+		return p, true, nil
+	}
+	return ReclaimPolicyProtect, false, nil
+}
+
+// deletionAllowed returns true if deletion of protected load balancer is explicitly allowed on the gateway
+func deletionAllowed(gw *gatewayv1.Gateway) bool {
+	allowed, _ := strconv.ParseBool(gw.Annotations[config.ALLOW_DELETION_KEY])
+	return allowed
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/mocks"
+
+	. "github.com/onsi/gomega"
+	"go.uber.org/mock/gomock"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_reclaimPolicy(t *testing.T) {
+	s := setupScheme(t)
+	tests := []struct {
+		name        string
+		gwPolicy    string
+		classPolicy string
+		expected    ReclaimPolicy
+		valid       bool
+	}{
+		{name: "default", expected: ReclaimPolicyDelete, valid: true},
+		{name: "from class", classPolicy: "Retain", expected: ReclaimPolicyRetain, valid: true},
+		{name: "gateway overrides class", gwPolicy: "Delete", classPolicy: "Protect", expected: ReclaimPolicyDelete, valid: true},
+		{name: "unknown treated as protect", gwPolicy: "retain", expected: ReclaimPolicyProtect, valid: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			gc := &gatewayv1.GatewayClass{ObjectMeta: metav1.ObjectMeta{Name: config.DEFAULT_GATEWAY_CLASS}}
+			if tt.classPolicy != "" {
+				gc.Annotations = map[string]string{config.RECLAIM_POLICY_KEY: tt.classPolicy}
+			}
+			gw := &gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{Name: testGw, Namespace: testGwNs},
+				Spec:       gatewayv1.GatewaySpec{GatewayClassName: config.DEFAULT_GATEWAY_CLASS},
+			}
+			if tt.gwPolicy != "" {
+				gw.Annotations = map[string]string{config.RECLAIM_POLICY_KEY: tt.gwPolicy}
+			}
+			r := &GatewayReconciler{Client: fake.NewClientBuilder().WithScheme(s).WithObjects(gc).Build()}
+
+			policy, valid, err := r.reclaimPolicy(context.Background(), gw)
+			g.Expect(err).To(BeNil())
+			g.Expect(policy).To(Equal(tt.expected))
+			g.Expect(valid).To(Equal(tt.valid))
+		})
+	}
+}
+
+func TestReconcile_ReclaimPolicy(t *testing.T) {
+	s := setupScheme(t)
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: config.DEFAULT_GATEWAY_CLASS},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController(config.DEFAULT_CONTROLLER_NAME),
+		},
+	}
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		setupMocks    func(lb *mocks.MockLBManagerInterface)
+		expectDeleted bool
+		expectEvent   string
+	}{
+		{
+			name: "delete",
+			setupMocks: func(lb *mocks.MockLBManagerInterface) {
+				lb.EXPECT().DeleteLB(gomock.Any(), "gw-uid").Return(nil)
+			},
+			expectDeleted: true,
+		},
+		{
+			name:        "retain",
+			annotations: map[string]string{config.RECLAIM_POLICY_KEY: "Retain"},
+			setupMocks: func(lb *mocks.MockLBManagerInterface) {
+				lb.EXPECT().RetainLB(gomock.Any(), "gw-uid").Return(nil)
+			},
+			expectDeleted: true,
+			expectEvent:   "Retained",
+		},
+		{
+			name:          "protect",
+			annotations:   map[string]string{config.RECLAIM_POLICY_KEY: "Protect"},
+			setupMocks:    func(lb *mocks.MockLBManagerInterface) {},
+			expectDeleted: false,
+			expectEvent:   "DeletionProtected",
+		},
+		{
+			name: "protect with deletion allowed",
+			annotations: map[string]string{
+				config.RECLAIM_POLICY_KEY: "Protect",
+				config.ALLOW_DELETION_KEY: "true",
+			},
+			setupMocks: func(lb *mocks.MockLBManagerInterface) {
+				lb.EXPECT().DeleteLB(gomock.Any(), "gw-uid").Return(nil)
+			},
+			expectDeleted: true,
+		},
+		{
+			name:          "unknown policy",
+			annotations:   map[string]string{config.RECLAIM_POLICY_KEY: "Keep"},
+			setupMocks:    func(lb *mocks.MockLBManagerInterface) {},
+			expectDeleted: false,
+			expectEvent:   "InvalidReclaimPolicy",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			now := metav1.Now()
+			gw := &gatewayv1.Gateway{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              testGw,
+					Namespace:         testGwNs,
+					UID:               "gw-uid",
+					Annotations:       tt.annotations,
+					Finalizers:        []string{config.GW_FINALIZER},
+					DeletionTimestamp: &now,
+				},
+				Spec: gatewayv1.GatewaySpec{GatewayClassName: config.DEFAULT_GATEWAY_CLASS},
+			}
+
+			ctrlr := gomock.NewController(t)
+			defer ctrlr.Finish()
+			mockLB := mocks.NewMockLBManagerInterface(ctrlr)
+			tt.setupMocks(mockLB)
+			fakeCli := fake.NewClientBuilder().WithScheme(s).WithObjects(gc.DeepCopy(), gw).Build()
+			recorder := record.NewFakeRecorder(8)
+			r := &GatewayReconciler{
+				Client:           fakeCli,
+				ControllerName:   config.DEFAULT_CONTROLLER_NAME,
+				GatewayClassName: config.DEFAULT_GATEWAY_CLASS,
+				LBMgr:            mockLB,
+				Recorder:         recorder,
+				Keys:             config.DefaultKeys(),
+			}
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: testGwNs, Name: testGw}}
+			_, err := r.Reconcile(context.Background(), req)
+			g.Expect(err).To(BeNil())
+
+			err = fakeCli.Get(context.Background(), req.NamespacedName, &gatewayv1.Gateway{})
+			g.Expect(apierrors.IsNotFound(err)).To(Equal(tt.expectDeleted))
+			if tt.expectEvent != "" {
+				g.Expect(recorder.Events).To(Receive(ContainSubstring(tt.expectEvent)))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+const (
+	kindHTTPRoute = "HTTPRoute"
+	kindGateway   = "Gateway"
+	kindService   = "Service"
+	kindSecret    = "Secret"
+)
+
+// objectRef describes one side of cross-namespace reference
+type objectRef struct {
+	Group     string
+	Kind      string
+	Namespace string
+	Name      string
+}
+
+// isReferenceAllowed returns true if reference from one object to another is allowed.
+// References within the same namespace are always allowed,
+// cross-namespace references require ReferenceGrant in the target namespace.
+func (r *GatewayReconciler) isReferenceAllowed(ctx context.Context, from, to objectRef) (bool, error) {
+	if from.Namespace == to.Namespace {
+		return true, nil
+	}
+	var grants gatewayv1beta1.ReferenceGrantList
+	if err := r.List(ctx, &grants, client.InNamespace(to.Namespace)); err != nil {
+		return false, fmt.Errorf("failed to list ReferenceGrants in namespace %q: %w", to.Namespace, err)
+	}
+	for _, grant := range grants.Items {
+		if referenceGrantAllows(&grant, from, to) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// referenceGrantAllows returns true if grant permits reference from one object to another.
+func referenceGrantAllows(grant *gatewayv1beta1.ReferenceGrant, from, to objectRef) bool {
+	if grant.Namespace != to.Namespace {
+		return false
+	}
+	fromMatched := false
+	for _, f := range grant.Spec.From {
+		if string(f.Group) == from.Group && string(f.Kind) == from.Kind && string(f.Namespace) == from.Namespace {
+			fromMatched = true
+			break
+		}
+	}
+	if !fromMatched {
+		return false
+	}
+	for _, t := range grant.Spec.To {
+		if string(t.Group) != to.Group || string(t.Kind) != to.Kind {
+			continue
+		}
+		if t.Name == nil || *t.Name == "" || string(*t.Name) == to.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// findGatewaysForReferenceGrant returns reconcile requests with gateways that affected by changes in ReferenceGrant
+func (r *GatewayReconciler) findGatewaysForReferenceGrant(ctx context.Context, obj client.Object) []reconcile.Request {
+	grant := obj.(*gatewayv1beta1.ReferenceGrant)
+	log := ctrl.LoggerFrom(ctx)
+
+	var keys []string
+	addKey := func(key string) {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+
+	for _, from := range grant.Spec.From {
+		if string(from.Group) != gatewayv1.GroupName {
+			continue
+		}
+		switch string(from.Kind) {
+		case kindHTTPRoute:
+			var routes gatewayv1.HTTPRouteList
+			if err := r.List(ctx, &routes, client.InNamespace(string(from.Namespace))); err != nil {
+				log.Error(err, "Failed to list HTTPRoutes for ReferenceGrant change", "referencegrant", grant.Name)
+				return nil
+			}
+			for i := range routes.Items {
+				if !routeReferencesNamespace(&routes.Items[i], grant.Namespace) {
+					continue
+				}
+				for _, key := range r.getParentGatewayKeys(&routes.Items[i]) {
+					addKey(key)
+				}
+			}
+		case kindGateway:
+			var gateways gatewayv1.GatewayList
+			if err := r.List(ctx, &gateways, client.InNamespace(string(from.Namespace))); err != nil {
+				log.Error(err, "Failed to list Gateways for ReferenceGrant change", "referencegrant", grant.Name)
+				return nil
+			}
+			for i := range gateways.Items {
+				if gatewayReferencesNamespace(&gateways.Items[i], grant.Namespace) {
+					addKey(gateways.Items[i].Namespace + "/" + gateways.Items[i].Name)
+				}
+			}
+		}
+	}
+
+	var requests []reconcile.Request
+	for _, key := range keys {
+		namespace, name, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil {
+			continue
+		}
+		var gw gatewayv1.Gateway
+		if err := r.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &gw); err != nil {
+			continue
+		}
+		managed, err := r.isManagedGateway(ctx, &gw)
+		if err != nil {
+			log.V(1).Info("Failed to check if gateway is managed", "referencegrant", grant.Name, "gateway", key, "error", err)
+			continue
+		}
+		if !managed {
+			continue
+		}
+		log.V(3).Info("ReferenceGrant change triggers Gateway reconcile", "referencegrant", grant.Name, "gateway", key)
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKey{Namespace: namespace, Name: name},
+		})
+	}
+	return requests
+}
+
+// routeReferencesNamespace returns true if route has backend in namespace other than its own.
+func routeReferencesNamespace(route *gatewayv1.HTTPRoute, ns string) bool {
+	if route.Namespace == ns {
+		return false
+	}
+	for _, rule := range route.Spec.Rules {
+		for _, ref := range rule.BackendRefs {
+			if ref.Namespace != nil && string(*ref.Namespace) == ns {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gatewayReferencesNamespace returns true if gateway has certificate in namespace other than its own.
+func gatewayReferencesNamespace(gw *gatewayv1.Gateway, ns string) bool {
+	if gw.Namespace == ns {
+		return false
+	}
+	for _, l := range gw.Spec.Listeners {
+		if l.TLS == nil {
+			continue
+		}
+		for _, ref := range l.TLS.CertificateRefs {
+			if ref.Namespace != nil && string(*ref.Namespace) == ns {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// backendObjectRef returns objectRef for Service referenced by route backend
+func backendObjectRef(ns, name string) objectRef {
+	return objectRef{Group: corev1.GroupName, Kind: kindService, Namespace: ns, Name: name}
+}
+
+// secretObjectRef returns objectRef for Secret referenced by Gateway listener
+func secretObjectRef(ns, name string) objectRef {
+	return objectRef{Group: corev1.GroupName, Kind: kindSecret, Namespace: ns, Name: name}
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gatewayv1beta1 "sigs.k8s.io/gateway-api/apis/v1beta1"
+)
+
+func newReferenceGrant(ns, fromKind, fromNS, toKind string, toName *string) *gatewayv1beta1.ReferenceGrant {
+	to := gatewayv1beta1.ReferenceGrantTo{Group: "", Kind: gatewayv1.Kind(toKind)}
+	if toName != nil {
+		name := gatewayv1.ObjectName(*toName)
+		to.Name = &name
+	}
+	return &gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: ns},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{{
+				Group:     gatewayv1.GroupName,
+				Kind:      gatewayv1.Kind(fromKind),
+				Namespace: gatewayv1.Namespace(fromNS),
+			}},
+			To: []gatewayv1beta1.ReferenceGrantTo{to},
+		},
+	}
+}
+
+func Test_referenceGrantAllows(t *testing.T) {
+	g := NewWithT(t)
+
+	from := objectRef{Group: gatewayv1.GroupName, Kind: kindHTTPRoute, Namespace: "routes"}
+	to := backendObjectRef("backends", "svc")
+	name, other := "svc", "other"
+
+	g.Expect(referenceGrantAllows(newReferenceGrant("backends", kindHTTPRoute, "routes", kindService, nil), from, to)).To(BeTrue())
+	g.Expect(referenceGrantAllows(newReferenceGrant("backends", kindHTTPRoute, "routes", kindService, &name), from, to)).To(BeTrue())
+	g.Expect(referenceGrantAllows(newReferenceGrant("backends", kindHTTPRoute, "routes", kindService, &other), from, to)).To(BeFalse())
+	// wrong from namespace
+	g.Expect(referenceGrantAllows(newReferenceGrant("backends", kindHTTPRoute, "other", kindService, nil), from, to)).To(BeFalse())
+	// wrong from kind
+	g.Expect(referenceGrantAllows(newReferenceGrant("backends", kindGateway, "routes", kindService, nil), from, to)).To(BeFalse())
+	// wrong to kind
+	g.Expect(referenceGrantAllows(newReferenceGrant("backends", kindHTTPRoute, "routes", kindSecret, nil), from, to)).To(BeFalse())
+	// grant in wrong namespace
+	g.Expect(referenceGrantAllows(newReferenceGrant("routes", kindHTTPRoute, "routes", kindService, nil), from, to)).To(BeFalse())
+}
+
+func Test_buildGatewayInfo_ReferenceGrant(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "backends"},
+		Spec:       corev1.ServiceSpec{Ports: []corev1.ServicePort{{Port: 80, NodePort: 30080}}},
+	}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "l1",
+				Protocol: gatewayv1.HTTPProtocolType,
+				Port:     80,
+			}},
+		},
+	}
+	backendNS := gatewayv1.Namespace("backends")
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: testGwNs},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"example.com"},
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}},
+			},
+			Rules: []gatewayv1.HTTPRouteRule{{
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{Name: "svc", Namespace: &backendNS},
+					},
+				}},
+			}},
+		},
+	}
+
+	// without grant
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node, ns, svc, gw, route).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli}
+
+	gi, err := r.buildGatewayInfo(context.Background(), gw, types.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(gi.VHosts["example.com"].Paths).To(BeEmpty())
+	resolved := meta.FindStatusCondition(gi.Routes[testGwNs+"/r1"].Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	g.Expect(resolved.Reason).To(Equal(string(gatewayv1.RouteReasonRefNotPermitted)))
+
+	// with grant
+	grant := newReferenceGrant("backends", kindHTTPRoute, testGwNs, kindService, nil)
+	fakeCli = fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(node, ns, svc, gw, route, grant).
+		Build()
+	r = &GatewayReconciler{Client: fakeCli}
+
+	gi, err = r.buildGatewayInfo(context.Background(), gw, types.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(gi.VHosts["example.com"].Paths).To(HaveLen(1))
+	g.Expect(meta.IsStatusConditionTrue(gi.Routes[testGwNs+"/r1"].Parents[0].Conditions, string(gatewayv1.RouteConditionResolvedRefs))).To(BeTrue())
+}
+
+func Test_buildTLSInfo_ReferenceGrant(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert", Namespace: "certs"},
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte("cert"),
+			corev1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+	certNS := gatewayv1.Namespace("certs")
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "https",
+				Protocol: gatewayv1.HTTPSProtocolType,
+				Port:     443,
+				Hostname: ptrHostname("secure.com"),
+				TLS: &gatewayv1.GatewayTLSConfig{
+					Mode: ptrTLSMode(gatewayv1.TLSModeTerminate),
+					CertificateRefs: []gatewayv1.SecretObjectReference{
+						{Name: "cert", Namespace: &certNS},
+					},
+				},
+			}},
+		},
+	}
+
+	// without grant
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, gw).Build()
+	r := &GatewayReconciler{Client: fakeCli}
+	tlsInfo, tlsConds, err := r.buildTLSInfo(context.Background(), gw)
+	g.Expect(err).To(BeNil())
+	g.Expect(tlsInfo).To(BeEmpty())
+	g.Expect(tlsConds).To(HaveKey("https"))
+	g.Expect(tlsConds["https"].Reason).To(Equal(string(gatewayv1.ListenerReasonRefNotPermitted)))
+
+	// with grant
+	grant := newReferenceGrant("certs", kindGateway, testGwNs, kindSecret, nil)
+	fakeCli = fake.NewClientBuilder().WithScheme(scheme).WithObjects(secret, gw, grant).Build()
+	r = &GatewayReconciler{Client: fakeCli}
+	tlsInfo, tlsConds, err = r.buildTLSInfo(context.Background(), gw)
+	g.Expect(err).To(BeNil())
+	g.Expect(tlsConds).To(BeEmpty())
+	g.Expect(tlsInfo).To(HaveKey("secure.com"))
+	g.Expect(tlsInfo["secure.com"].Secret.Namespace).To(Equal("certs"))
+}
+
+func Test_findGatewaysForReferenceGrant(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	gc := &gatewayv1.GatewayClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "gc1"},
+		Spec: gatewayv1.GatewayClassSpec{
+			ControllerName: gatewayv1.GatewayController("example.com/controller"),
+		},
+	}
+	certNS := gatewayv1.Namespace("shared")
+	gwRoutes := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw-routes", Namespace: "gw-ns"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc1"},
+	}
+	gwCerts := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw-certs", Namespace: "gw-ns"},
+		Spec: gatewayv1.GatewaySpec{
+			GatewayClassName: "gc1",
+			Listeners: []gatewayv1.Listener{{
+				TLS: &gatewayv1.GatewayTLSConfig{
+					CertificateRefs: []gatewayv1.SecretObjectReference{{Name: "cert", Namespace: &certNS}},
+				},
+			}},
+		},
+	}
+	gwUnrelated := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw-unrelated", Namespace: "gw-ns"},
+		Spec:       gatewayv1.GatewaySpec{GatewayClassName: "gc1"},
+	}
+	gwNS := gatewayv1.Namespace("gw-ns")
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: "routes"},
+		Spec: gatewayv1.HTTPRouteSpec{
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Name: "gw-routes", Namespace: &gwNS}},
+			},
+			Rules: []gatewayv1.HTTPRouteRule{{
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{Name: "svc", Namespace: &certNS},
+					},
+				}},
+			}},
+		},
+	}
+	grant := &gatewayv1beta1.ReferenceGrant{
+		ObjectMeta: metav1.ObjectMeta{Name: "grant", Namespace: "shared"},
+		Spec: gatewayv1beta1.ReferenceGrantSpec{
+			From: []gatewayv1beta1.ReferenceGrantFrom{
+				{Group: gatewayv1.GroupName, Kind: kindHTTPRoute, Namespace: "routes"},
+				{Group: gatewayv1.GroupName, Kind: kindGateway, Namespace: "gw-ns"},
+			},
+			To: []gatewayv1beta1.ReferenceGrantTo{{Kind: kindService}, {Kind: kindSecret}},
+		},
+	}
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(gc, gwRoutes, gwCerts, gwUnrelated, route, grant).
+		Build()
+	r := &GatewayReconciler{
+		Client:         fakeCli,
+		ControllerName: "example.com/controller",
+	}
+	reqs := r.findGatewaysForReferenceGrant(context.Background(), grant)
+	var names []string
+	for _, req := range reqs {
+		names = append(names, req.Name)
+	}
+	g.Expect(names).To(ConsistOf("gw-routes", "gw-certs"))
+}
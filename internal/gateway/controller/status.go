@@ -0,0 +1,182 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// updateRouteStatuses writes parent statuses of routes referencing the gateway.
+// Only entries written by this controller for this gateway are changed, others are left as is.
+// Patches use optimistic lock so entries written by other controllers meanwhile aren't lost,
+// on conflict the error is returned and the gateway is requeued with fresh routes.
+func (r *GatewayReconciler) updateRouteStatuses(ctx context.Context, gw *gatewayv1.Gateway, routes map[string]*types.RouteInfo) error {
+	for key, info := range routes {
+		route := info.Route
+		parents := r.buildRouteParentStatuses(route, gw, info.Parents)
+		if equality.Semantic.DeepEqual(route.Status.Parents, parents) {
+			continue
+		}
+		orig := route.DeepCopy()
+		route.Status.Parents = parents
+		if err := r.Status().Patch(ctx, route, client.MergeFromWithOptions(orig, client.MergeFromWithOptimisticLock{})); err != nil {
+			return fmt.Errorf("failed to update HTTPRoute %s status: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// buildRouteParentStatuses merges desired parent statuses into route status.
+// Entries are replaced in place to keep order stable between reconciles.
+func (r *GatewayReconciler) buildRouteParentStatuses(
+	route *gatewayv1.HTTPRoute,
+	gw *gatewayv1.Gateway,
+	desired []types.RouteParentInfo,
+) []gatewayv1.RouteParentStatus {
+	used := make([]bool, len(desired))
+	var parents []gatewayv1.RouteParentStatus
+
+	for _, ps := range route.Status.Parents {
+		if string(ps.ControllerName) != r.ControllerName || !parentRefMatchesGateway(ps.ParentRef, route.Namespace, gw) {
+			parents = append(parents, ps)
+			continue
+		}
+		for i, d := range desired {
+			if used[i] || !equality.Semantic.DeepEqual(ps.ParentRef, d.ParentRef) {
+				continue
+			}
+			used[i] = true
+			parents = append(parents, gatewayv1.RouteParentStatus{
+				ParentRef:      d.ParentRef,
+				ControllerName: ps.ControllerName,
+				Conditions:     mergeConditions(ps.Conditions, d.Conditions),
+			})
+			break
+		}
+	}
+	for i, d := range desired {
+		if used[i] {
+			continue
+		}
+		parents = append(parents, gatewayv1.RouteParentStatus{
+			ParentRef:      d.ParentRef,
+			ControllerName: gatewayv1.GatewayController(r.ControllerName),
+			Conditions:     mergeConditions(nil, d.Conditions),
+		})
+	}
+	return parents
+}
+
+// setGatewayStatus sets gateway conditions along with listeners status in one patch.
+// Addresses are updated only if not nil.
+func (r *GatewayReconciler) setGatewayStatus(
+	ctx context.Context,
+	gw *gatewayv1.Gateway,
+	listeners map[string]*types.ListenerStatusInfo,
+	addresses []gatewayv1.GatewayStatusAddress,
+	conds ...metav1.Condition,
+) error {
+	orig := gw.DeepCopy()
+	for _, c := range conds {
+		c.ObservedGeneration = gw.Generation
+		meta.SetStatusCondition(&gw.Status.Conditions, c)
+	}
+	if addresses != nil {
+		gw.Status.Addresses = addresses
+	}
+	programmed := meta.IsStatusConditionTrue(gw.Status.Conditions, string(gatewayv1.GatewayConditionProgrammed))
+	gw.Status.Listeners = buildListenerStatuses(gw, listeners, programmed)
+	return r.Status().Patch(ctx, gw, client.MergeFrom(orig))
+}
+
+// buildListenerStatuses converts listeners info into gateway listeners status.
+// Valid listeners are Programmed only when gateway itself is programmed.
+func buildListenerStatuses(gw *gatewayv1.Gateway, listeners map[string]*types.ListenerStatusInfo, programmed bool) []gatewayv1.ListenerStatus {
+	var res []gatewayv1.ListenerStatus
+	for _, l := range gw.Spec.Listeners {
+		info, ok := listeners[string(l.Name)]
+		if !ok {
+			continue
+		}
+		progCond := metav1.Condition{
+			Type:               string(gatewayv1.ListenerConditionProgrammed),
+			Status:             metav1.ConditionTrue,
+			Reason:             string(gatewayv1.ListenerReasonProgrammed),
+			Message:            "Listener is programmed",
+			ObservedGeneration: gw.Generation,
+		}
+		switch {
+		case !isListenerValid(info):
+			progCond.Status = metav1.ConditionFalse
+			progCond.Reason = string(gatewayv1.ListenerReasonInvalid)
+			progCond.Message = "Listener is not valid"
+		case !programmed:
+			progCond.Status = metav1.ConditionFalse
+			progCond.Reason = string(gatewayv1.ListenerReasonPending)
+			progCond.Message = "Waiting for load balancer to be programmed"
+		}
+		desired := append(append([]metav1.Condition{}, info.Conditions...), progCond)
+
+		var existing []metav1.Condition
+		for _, ls := range gw.Status.Listeners {
+			if ls.Name == l.Name {
+				existing = ls.Conditions
+				break
+			}
+		}
+		res = append(res, gatewayv1.ListenerStatus{
+			Name:           l.Name,
+			SupportedKinds: info.SupportedKinds,
+			AttachedRoutes: info.AttachedRoutes,
+			Conditions:     mergeConditions(existing, desired),
+		})
+	}
+	return res
+}
+
+// setGatewayProgrammed sets gateway Programmed condition along with listeners status.
+func (r *GatewayReconciler) setGatewayProgrammed(
+	ctx context.Context,
+	gw *gatewayv1.Gateway,
+	listeners map[string]*types.ListenerStatusInfo,
+	reason, message string,
+	status metav1.ConditionStatus,
+) error {
+	cond := metav1.Condition{
+		Type:    string(gatewayv1.GatewayConditionProgrammed),
+		Status:  status,
+		Reason:  reason,
+		Message: message,
+	}
+	return r.setGatewayStatus(ctx, gw, listeners, nil, cond)
+}
+
+// statusAddresses returns gateway status addresses of LB external addresses.
+// IPv4 addresses go first, IPv6 ones of dual stack LB are published as additional entries.
+func statusAddresses(external []string) []gatewayv1.GatewayStatusAddress {
+	var v4, v6 []gatewayv1.GatewayStatusAddress
+	seen := make(map[string]bool)
+	for _, value := range external {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		switch ipFamily(value) {
+		case corev1.IPv4Protocol:
+			v4 = append(v4, gatewayv1.GatewayStatusAddress{Type: &IPAddressType, Value: value})
+		case corev1.IPv6Protocol:
+			v6 = append(v6, gatewayv1.GatewayStatusAddress{Type: &IPAddressType, Value: value})
+		default:
+			v4 = append(v4, gatewayv1.GatewayStatusAddress{Type: &HostnameAddressType, Value: value})
+		}
+	}
+	return append(v4, v6...)
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/internal/types"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func Test_updateRouteStatuses(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "n1"},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: "10.0.0.10"}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testGwNs}}
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs},
+		Spec: gatewayv1.GatewaySpec{
+			Listeners: []gatewayv1.Listener{{
+				Name:     "l1",
+				Protocol: gatewayv1.HTTPProtocolType,
+				Port:     80,
+			}},
+		},
+	}
+	foreign := gatewayv1.RouteParentStatus{
+		ParentRef:      gatewayv1.ParentReference{Name: "other-gw"},
+		ControllerName: "other.io/controller",
+		Conditions: []metav1.Condition{{
+			Type:               string(gatewayv1.RouteConditionAccepted),
+			Status:             metav1.ConditionTrue,
+			Reason:             string(gatewayv1.RouteReasonAccepted),
+			LastTransitionTime: metav1.Now(),
+		}},
+	}
+	// route referencing missing service
+	route := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r1", Namespace: testGwNs, Generation: 3},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"example.com"},
+			CommonRouteSpec: gatewayv1.CommonRouteSpec{
+				ParentRefs: []gatewayv1.ParentReference{{Name: "gw1"}},
+			},
+			Rules: []gatewayv1.HTTPRouteRule{{
+				BackendRefs: []gatewayv1.HTTPBackendRef{{
+					BackendRef: gatewayv1.BackendRef{
+						BackendObjectReference: gatewayv1.BackendObjectReference{Name: "missing"},
+					},
+				}},
+			}},
+		},
+		Status: gatewayv1.HTTPRouteStatus{
+			RouteStatus: gatewayv1.RouteStatus{Parents: []gatewayv1.RouteParentStatus{foreign}},
+		},
+	}
+	// route that doesn't reference gateway anymore but still has our status
+	detached := &gatewayv1.HTTPRoute{
+		ObjectMeta: metav1.ObjectMeta{Name: "r2", Namespace: testGwNs},
+		Spec: gatewayv1.HTTPRouteSpec{
+			Hostnames: []gatewayv1.Hostname{"detached.com"},
+		},
+		Status: gatewayv1.HTTPRouteStatus{
+			RouteStatus: gatewayv1.RouteStatus{Parents: []gatewayv1.RouteParentStatus{{
+				ParentRef:      gatewayv1.ParentReference{Name: "gw1"},
+				ControllerName: "example.com/controller",
+			}}},
+		},
+	}
+
+	fakeCli := fake.NewClientBuilder().WithScheme(scheme).
+		WithStatusSubresource(&gatewayv1.HTTPRoute{}).
+		WithObjects(node, ns, gw, route, detached).
+		Build()
+	r := &GatewayReconciler{Client: fakeCli, ControllerName: "example.com/controller"}
+
+	gwInfo, err := r.buildGatewayInfo(context.Background(), gw, types.LBParams{}, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(gwInfo.VHosts["example.com"].Paths).To(BeEmpty())
+	g.Expect(r.updateRouteStatuses(context.Background(), gw, gwInfo.Routes)).To(Succeed())
+
+	var got gatewayv1.HTTPRoute
+	g.Expect(fakeCli.Get(context.Background(), client.ObjectKeyFromObject(route), &got)).To(Succeed())
+	g.Expect(got.Status.Parents).To(HaveLen(2))
+	g.Expect(got.Status.Parents[0].ControllerName).To(Equal(foreign.ControllerName))
+	ours := got.Status.Parents[1]
+	g.Expect(string(ours.ControllerName)).To(Equal("example.com/controller"))
+	accepted := meta.FindStatusCondition(ours.Conditions, string(gatewayv1.RouteConditionAccepted))
+	g.Expect(accepted).ToNot(BeNil())
+	g.Expect(accepted.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(accepted.ObservedGeneration).To(Equal(int64(3)))
+	resolved := meta.FindStatusCondition(ours.Conditions, string(gatewayv1.RouteConditionResolvedRefs))
+	g.Expect(resolved).ToNot(BeNil())
+	g.Expect(resolved.Status).To(Equal(metav1.ConditionFalse))
+	g.Expect(resolved.Reason).To(Equal(string(gatewayv1.RouteReasonBackendNotFound)))
+
+	g.Expect(fakeCli.Get(context.Background(), client.ObjectKeyFromObject(detached), &got)).To(Succeed())
+	g.Expect(got.Status.Parents).To(BeEmpty())
+
+	// stale route must not overwrite status written meanwhile
+	g.Expect(fakeCli.Get(context.Background(), client.ObjectKeyFromObject(route), &got)).To(Succeed())
+	stale := got.DeepCopy()
+	got.Status.Parents = append(got.Status.Parents, gatewayv1.RouteParentStatus{
+		ParentRef:      gatewayv1.ParentReference{Name: "third-gw"},
+		ControllerName: "third.io/controller",
+	})
+	g.Expect(fakeCli.Status().Update(context.Background(), &got)).To(Succeed())
+	stale.Status.Parents = stale.Status.Parents[:1]
+	gwInfo.Routes[client.ObjectKeyFromObject(route).String()].Route = stale
+	err = r.updateRouteStatuses(context.Background(), gw, gwInfo.Routes)
+	g.Expect(apierrors.IsConflict(err)).To(BeTrue())
+	g.Expect(fakeCli.Get(context.Background(), client.ObjectKeyFromObject(route), &got)).To(Succeed())
+	g.Expect(got.Status.Parents).To(HaveLen(3))
+}
+
+func Test_statusAddresses(t *testing.T) {
+	g := NewWithT(t)
+
+	addresses := statusAddresses([]string{"2001:db8::1", "1.1.1.1", "lb.example.com", "1.1.1.1", "2.2.2.2"})
+	g.Expect(addresses).To(Equal([]gatewayv1.GatewayStatusAddress{
+		{Type: &IPAddressType, Value: "1.1.1.1"},
+		{Type: &HostnameAddressType, Value: "lb.example.com"},
+		{Type: &IPAddressType, Value: "2.2.2.2"},
+		{Type: &IPAddressType, Value: "2001:db8::1"},
+	}))
+	g.Expect(statusAddresses(nil)).To(BeEmpty())
+}
@@ -0,0 +1,184 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/metrics"
+	lbsrv "github.com/serverscom/api-gateway-controller/internal/service/lb"
+	tlssrv "github.com/serverscom/api-gateway-controller/internal/service/tls"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+const (
+	orphanKindLB          = "L7LoadBalancer"
+	orphanKindCertificate = "SSLCertificate"
+)
+
+// OrphanSweeper periodically deletes provider resources created by controller
+// which owners (Gateway or Secret) don't exist anymore.
+// Orphan is deleted only if it stays orphaned during GracePeriod.
+type OrphanSweeper struct {
+	client.Client
+	Recorder record.EventRecorder
+
+	LBMgr  lbsrv.LBManagerInterface
+	TLSMgr tlssrv.TLSManagerInterface
+
+	Interval    time.Duration
+	GracePeriod time.Duration
+	// DryRun only reports orphans without deleting them
+	DryRun bool
+	// EventNamespace is a namespace for events about orphans, since they have no k8s object
+	EventNamespace string
+	// ClusterID limits sweeping to resources labelled with this cluster
+	ClusterID string
+	// Keys contains label keys of this controller instance
+	Keys config.Keys
+
+	// firstSeen contains time when resource was found orphaned for the first time
+	firstSeen map[string]time.Time
+	now       func() time.Time
+}
+
+// SetupWithManager adds sweeper to Manager, it runs only on leader
+func (s *OrphanSweeper) SetupWithManager(mgr ctrl.Manager) error {
+	return mgr.Add(s)
+}
+
+// NeedLeaderElection implements manager.LeaderElectionRunnable
+func (s *OrphanSweeper) NeedLeaderElection() bool {
+	return true
+}
+
+// Start runs sweep loop until context is done
+func (s *OrphanSweeper) Start(ctx context.Context) error {
+	log := ctrl.LoggerFrom(ctx).WithName("orphan-sweeper")
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.Sweep(ctx); err != nil {
+				log.Error(err, "Orphans sweep failed")
+			}
+		}
+	}
+}
+
+// Sweep finds orphaned load balancers and certificates and deletes ones orphaned longer than GracePeriod.
+func (s *OrphanSweeper) Sweep(ctx context.Context) error {
+	if s.firstSeen == nil {
+		s.firstSeen = make(map[string]time.Time)
+	}
+	if s.now == nil {
+		s.now = time.Now
+	}
+
+	var gateways gatewayv1.GatewayList
+	if err := s.List(ctx, &gateways); err != nil {
+		return fmt.Errorf("failed to list Gateways: %w", err)
+	}
+	gatewayUIDs := make(map[string]bool, len(gateways.Items))
+	for _, gw := range gateways.Items {
+		gatewayUIDs[string(gw.UID)] = true
+	}
+
+	var secrets corev1.SecretList
+	if err := s.List(ctx, &secrets); err != nil {
+		return fmt.Errorf("failed to list Secrets: %w", err)
+	}
+	secretUIDs := make(map[string]bool, len(secrets.Items))
+	for _, secret := range secrets.Items {
+		secretUIDs[string(secret.UID)] = true
+	}
+
+	lbs, err := s.LBMgr.ListManagedLBs(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list load balancers: %w", err)
+	}
+	certs, err := s.TLSMgr.ListManagedCertificates(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list certificates: %w", err)
+	}
+
+	// certificate of deleted secret may still be used until Gateway switches to another one
+	unused, settled := unusedCertificates(certs, lbs, s.Keys, s.ClusterID)
+	if !settled {
+		unused = nil
+	}
+
+	seen := make(map[string]bool)
+	for _, lb := range lbs {
+		uid := lb.Labels[s.Keys.GatewayLabel]
+		if uid == "" || gatewayUIDs[uid] || !utils.OwnedByCluster(lb.Labels, s.Keys, s.ClusterID) {
+			continue
+		}
+		key := orphanKindLB + "/" + lb.ID
+		seen[key] = true
+		s.handleOrphan(ctx, key, orphanKindLB, lb.Name, func() error {
+			return s.LBMgr.DeleteLBByID(ctx, lb.ID)
+		})
+	}
+	for _, cert := range unused {
+		uid := cert.Labels[s.Keys.SecretLabel]
+		if uid == "" || secretUIDs[uid] {
+			continue
+		}
+		key := orphanKindCertificate + "/" + cert.ID
+		seen[key] = true
+		s.handleOrphan(ctx, key, orphanKindCertificate, cert.Name, func() error {
+			return s.TLSMgr.DeleteCertificate(ctx, cert.ID)
+		})
+	}
+
+	// forget resources which are not orphaned anymore
+	for key := range s.firstSeen {
+		if !seen[key] {
+			delete(s.firstSeen, key)
+		}
+	}
+	return nil
+}
+
+// handleOrphan deletes orphan if it was orphaned longer than grace period
+func (s *OrphanSweeper) handleOrphan(ctx context.Context, key, kind, name string, deleteFn func() error) {
+	log := ctrl.LoggerFrom(ctx).WithName("orphan-sweeper")
+	first, ok := s.firstSeen[key]
+	if !ok {
+		s.firstSeen[key] = s.now()
+		log.Info("Orphaned resource found", "kind", kind, "name", name, "gracePeriod", s.GracePeriod)
+		return
+	}
+	if s.now().Sub(first) < s.GracePeriod {
+		return
+	}
+
+	ref := &corev1.ObjectReference{Kind: kind, Name: name, Namespace: s.EventNamespace}
+	if s.DryRun {
+		log.Info("Orphaned resource would be deleted (dry-run)", "kind", kind, "name", name)
+		s.Recorder.Eventf(ref, corev1.EventTypeNormal, "OrphanDeleted", "Orphaned %s %s would be deleted (dry-run)", kind, name)
+		metrics.OrphansDeletedTotal.WithLabelValues(kind, strconv.FormatBool(true)).Inc()
+		return
+	}
+	if err := deleteFn(); err != nil {
+		log.Error(err, "Failed to delete orphaned resource", "kind", kind, "name", name)
+		s.Recorder.Eventf(ref, corev1.EventTypeWarning, "OrphanDeleteFailed", "Failed to delete orphaned %s %s: %v", kind, name, err)
+		return
+	}
+	log.Info("Orphaned resource deleted", "kind", kind, "name", name)
+	s.Recorder.Eventf(ref, corev1.EventTypeNormal, "OrphanDeleted", "Orphaned %s %s deleted", kind, name)
+	metrics.OrphansDeletedTotal.WithLabelValues(kind, strconv.FormatBool(false)).Inc()
+	delete(s.firstSeen, key)
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+	"github.com/serverscom/api-gateway-controller/internal/metrics"
+	"github.com/serverscom/api-gateway-controller/internal/mocks"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	serverscom "github.com/serverscom/serverscom-go-client/pkg"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestOrphanSweeper_Sweep(t *testing.T) {
+	scheme := setupScheme(t)
+
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs, UID: "gw-live"},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert", Namespace: testGwNs, UID: "secret-live"},
+	}
+	lbs := []serverscom.LoadBalancer{
+		{ID: "lb-live", Name: "gw-live", Status: config.LB_ACTIVE_STATUS, Labels: map[string]string{config.GW_LABEL_ID: "gw-live"}},
+		{ID: "lb-orphan", Name: "gw-orphan", Status: config.LB_ACTIVE_STATUS, Labels: map[string]string{config.GW_LABEL_ID: "gw-deleted"}},
+	}
+	certs := []serverscom.SSLCertificate{
+		{ID: "cert-live", Name: "gw-secret-live", Labels: map[string]string{config.SECRET_LABEL_ID: "secret-live"}},
+		{ID: "cert-orphan", Name: "gw-secret-orphan", Labels: map[string]string{config.SECRET_LABEL_ID: "secret-deleted"}},
+	}
+
+	tests := []struct {
+		name       string
+		dryRun     bool
+		setupMocks func(lbMgr *mocks.MockLBManagerInterface, tlsMgr *mocks.MockTLSManagerInterface)
+	}{
+		{
+			name: "orphans deleted after grace period",
+			setupMocks: func(lbMgr *mocks.MockLBManagerInterface, tlsMgr *mocks.MockTLSManagerInterface) {
+				lbMgr.EXPECT().DeleteLBByID(gomock.Any(), "lb-orphan").Return(nil)
+				tlsMgr.EXPECT().DeleteCertificate(gomock.Any(), "cert-orphan").Return(nil)
+			},
+		},
+		{
+			name:       "dry run doesn't delete",
+			dryRun:     true,
+			setupMocks: func(lbMgr *mocks.MockLBManagerInterface, tlsMgr *mocks.MockTLSManagerInterface) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+			metrics.OrphansDeletedTotal.Reset()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+			lbMgr := mocks.NewMockLBManagerInterface(mockCtrl)
+			tlsMgr := mocks.NewMockTLSManagerInterface(mockCtrl)
+			lbMgr.EXPECT().ListManagedLBs(gomock.Any()).Return(lbs, nil).Times(3)
+			tlsMgr.EXPECT().ListManagedCertificates(gomock.Any()).Return(certs, nil).Times(3)
+			tc.setupMocks(lbMgr, tlsMgr)
+
+			recorder := record.NewFakeRecorder(10)
+			now := time.Now()
+			s := &OrphanSweeper{
+				Client:         fake.NewClientBuilder().WithScheme(scheme).WithObjects(gw, secret).Build(),
+				Recorder:       recorder,
+				Keys:           config.DefaultKeys(),
+				LBMgr:          lbMgr,
+				TLSMgr:         tlsMgr,
+				GracePeriod:    time.Hour,
+				DryRun:         tc.dryRun,
+				EventNamespace: "default",
+				now:            func() time.Time { return now },
+			}
+
+			// first seen
+			g.Expect(s.Sweep(context.Background())).To(Succeed())
+			g.Expect(s.firstSeen).To(HaveLen(2))
+			g.Expect(recorder.Events).To(BeEmpty())
+
+			// grace period not passed yet
+			now = now.Add(30 * time.Minute)
+			g.Expect(s.Sweep(context.Background())).To(Succeed())
+			g.Expect(recorder.Events).To(BeEmpty())
+
+			now = now.Add(time.Hour)
+			g.Expect(s.Sweep(context.Background())).To(Succeed())
+			g.Expect(recorder.Events).To(HaveLen(2))
+			g.Expect(<-recorder.Events).To(ContainSubstring("OrphanDeleted"))
+
+			dryRun := "false"
+			if tc.dryRun {
+				dryRun = "true"
+			}
+			g.Expect(testutil.ToFloat64(metrics.OrphansDeletedTotal.WithLabelValues(orphanKindLB, dryRun))).To(Equal(1.0))
+			g.Expect(testutil.ToFloat64(metrics.OrphansDeletedTotal.WithLabelValues(orphanKindCertificate, dryRun))).To(Equal(1.0))
+		})
+	}
+}
+
+func TestOrphanSweeper_ForgetsAdoptedResources(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	lbMgr := mocks.NewMockLBManagerInterface(mockCtrl)
+	tlsMgr := mocks.NewMockTLSManagerInterface(mockCtrl)
+
+	orphan := []serverscom.LoadBalancer{
+		{ID: "lb1", Name: "gw-lb1", Labels: map[string]string{config.GW_LABEL_ID: "gw1"}},
+	}
+	lbMgr.EXPECT().ListManagedLBs(gomock.Any()).Return(orphan, nil)
+	lbMgr.EXPECT().ListManagedLBs(gomock.Any()).Return(nil, nil)
+	tlsMgr.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil).Times(2)
+
+	s := &OrphanSweeper{
+		Client:      fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Recorder:    record.NewFakeRecorder(10),
+		Keys:        config.DefaultKeys(),
+		LBMgr:       lbMgr,
+		TLSMgr:      tlsMgr,
+		GracePeriod: time.Hour,
+	}
+	g.Expect(s.Sweep(context.Background())).To(Succeed())
+	g.Expect(s.firstSeen).To(HaveKey(orphanKindLB + "/lb1"))
+
+	g.Expect(s.Sweep(context.Background())).To(Succeed())
+	g.Expect(s.firstSeen).To(BeEmpty())
+}
+
+func TestOrphanSweeper_KeepsCertificatesInUse(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	lbMgr := mocks.NewMockLBManagerInterface(mockCtrl)
+	tlsMgr := mocks.NewMockTLSManagerInterface(mockCtrl)
+
+	gw := &gatewayv1.Gateway{
+		ObjectMeta: metav1.ObjectMeta{Name: "gw1", Namespace: testGwNs, UID: "gw1"},
+	}
+	lbs := []serverscom.LoadBalancer{{
+		ID:     "lb1",
+		Status: config.LB_ACTIVE_STATUS,
+		Labels: map[string]string{config.GW_LABEL_ID: "gw1", config.LB_CERT_LABEL_PREFIX + "cert1": "true"},
+	}}
+	certs := []serverscom.SSLCertificate{
+		{ID: "cert1", Labels: map[string]string{config.SECRET_LABEL_ID: "secret-deleted"}},
+	}
+	lbMgr.EXPECT().ListManagedLBs(gomock.Any()).Return(lbs, nil)
+	tlsMgr.EXPECT().ListManagedCertificates(gomock.Any()).Return(certs, nil)
+
+	s := &OrphanSweeper{
+		Client:   fake.NewClientBuilder().WithScheme(scheme).WithObjects(gw).Build(),
+		Recorder: record.NewFakeRecorder(10),
+		Keys:     config.DefaultKeys(),
+		LBMgr:    lbMgr,
+		TLSMgr:   tlsMgr,
+	}
+	g.Expect(s.Sweep(context.Background())).To(Succeed())
+	g.Expect(s.firstSeen).To(BeEmpty())
+}
+
+func TestOrphanSweeper_SkipsOtherClusters(t *testing.T) {
+	g := NewWithT(t)
+	scheme := setupScheme(t)
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	lbMgr := mocks.NewMockLBManagerInterface(mockCtrl)
+	tlsMgr := mocks.NewMockTLSManagerInterface(mockCtrl)
+
+	lbs := []serverscom.LoadBalancer{
+		{ID: "legacy", Status: config.LB_ACTIVE_STATUS, Labels: map[string]string{config.GW_LABEL_ID: "gw1"}},
+		{ID: "own", Status: config.LB_ACTIVE_STATUS, Labels: map[string]string{config.GW_LABEL_ID: "gw2", config.CLUSTER_LABEL_ID: "a"}},
+	}
+	lbMgr.EXPECT().ListManagedLBs(gomock.Any()).Return(lbs, nil)
+	tlsMgr.EXPECT().ListManagedCertificates(gomock.Any()).Return(nil, nil)
+
+	s := &OrphanSweeper{
+		Client:    fake.NewClientBuilder().WithScheme(scheme).Build(),
+		Recorder:  record.NewFakeRecorder(10),
+		Keys:      config.DefaultKeys(),
+		LBMgr:     lbMgr,
+		TLSMgr:    tlsMgr,
+		ClusterID: "a",
+	}
+	g.Expect(s.Sweep(context.Background())).To(Succeed())
+	g.Expect(s.firstSeen).To(HaveLen(1))
+	g.Expect(s.firstSeen).To(HaveKey(orphanKindLB + "/own"))
+}
@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// DriftDetectedTotal counts load balancers found different from desired state, per Gateway
+	DriftDetectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_lb_drift_detected_total",
+			Help: "Number of times managed load balancer was found different from desired state",
+		},
+		[]string{"namespace", "name"},
+	)
+
+	// OrphansDeletedTotal counts deleted orphaned provider resources, per resource kind
+	OrphansDeletedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_orphans_deleted_total",
+			Help: "Number of orphaned provider resources deleted (or would be deleted in dry-run mode)",
+		},
+		[]string{"kind", "dry_run"},
+	)
+)
+
+func init() {
+	ctrlmetrics.Registry.MustRegister(DriftDetectedTotal, OrphansDeletedTotal)
+}
@@ -43,17 +43,46 @@ func (m *MockLBManagerInterface) EXPECT() *MockLBManagerInterfaceMockRecorder {
 }
 
 // DeleteLB mocks base method.
-func (m *MockLBManagerInterface) DeleteLB(ctx context.Context, labelSelector string) error {
+func (m *MockLBManagerInterface) DeleteLB(ctx context.Context, gatewayUID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteLB", ctx, labelSelector)
+	ret := m.ctrl.Call(m, "DeleteLB", ctx, gatewayUID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteLB indicates an expected call of DeleteLB.
-func (mr *MockLBManagerInterfaceMockRecorder) DeleteLB(ctx, labelSelector any) *gomock.Call {
+func (mr *MockLBManagerInterfaceMockRecorder) DeleteLB(ctx, gatewayUID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLB", reflect.TypeOf((*MockLBManagerInterface)(nil).DeleteLB), ctx, labelSelector)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLB", reflect.TypeOf((*MockLBManagerInterface)(nil).DeleteLB), ctx, gatewayUID)
+}
+
+// DeleteLBByID mocks base method.
+func (m *MockLBManagerInterface) DeleteLBByID(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteLBByID", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteLBByID indicates an expected call of DeleteLBByID.
+func (mr *MockLBManagerInterfaceMockRecorder) DeleteLBByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteLBByID", reflect.TypeOf((*MockLBManagerInterface)(nil).DeleteLBByID), ctx, id)
+}
+
+// DetectDrift mocks base method.
+func (m *MockLBManagerInterface) DetectDrift(ctx context.Context, gwInfo *types.GatewayInfo, hostCertMap map[string]string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DetectDrift", ctx, gwInfo, hostCertMap)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DetectDrift indicates an expected call of DetectDrift.
+func (mr *MockLBManagerInterfaceMockRecorder) DetectDrift(ctx, gwInfo, hostCertMap any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetectDrift", reflect.TypeOf((*MockLBManagerInterface)(nil).DetectDrift), ctx, gwInfo, hostCertMap)
 }
 
 // EnsureLB mocks base method.
@@ -70,3 +99,32 @@ func (mr *MockLBManagerInterfaceMockRecorder) EnsureLB(ctx, gwInfo, hostCertMap
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureLB", reflect.TypeOf((*MockLBManagerInterface)(nil).EnsureLB), ctx, gwInfo, hostCertMap)
 }
+
+// ListManagedLBs mocks base method.
+func (m *MockLBManagerInterface) ListManagedLBs(ctx context.Context) ([]serverscom.LoadBalancer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListManagedLBs", ctx)
+	ret0, _ := ret[0].([]serverscom.LoadBalancer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListManagedLBs indicates an expected call of ListManagedLBs.
+func (mr *MockLBManagerInterfaceMockRecorder) ListManagedLBs(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListManagedLBs", reflect.TypeOf((*MockLBManagerInterface)(nil).ListManagedLBs), ctx)
+}
+
+// RetainLB mocks base method.
+func (m *MockLBManagerInterface) RetainLB(ctx context.Context, gatewayUID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetainLB", ctx, gatewayUID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetainLB indicates an expected call of RetainLB.
+func (mr *MockLBManagerInterfaceMockRecorder) RetainLB(ctx, gatewayUID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetainLB", reflect.TypeOf((*MockLBManagerInterface)(nil).RetainLB), ctx, gatewayUID)
+}
@@ -14,6 +14,7 @@ import (
 	reflect "reflect"
 
 	types "github.com/serverscom/api-gateway-controller/internal/types"
+	serverscom "github.com/serverscom/serverscom-go-client/pkg"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,6 +42,20 @@ func (m *MockTLSManagerInterface) EXPECT() *MockTLSManagerInterfaceMockRecorder
 	return m.recorder
 }
 
+// DeleteCertificate mocks base method.
+func (m *MockTLSManagerInterface) DeleteCertificate(ctx context.Context, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteCertificate", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCertificate indicates an expected call of DeleteCertificate.
+func (mr *MockTLSManagerInterfaceMockRecorder) DeleteCertificate(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCertificate", reflect.TypeOf((*MockTLSManagerInterface)(nil).DeleteCertificate), ctx, id)
+}
+
 // EnsureTLS mocks base method.
 func (m *MockTLSManagerInterface) EnsureTLS(ctx context.Context, tlsInfo map[string]types.TLSConfigInfo) (map[string]string, error) {
 	m.ctrl.T.Helper()
@@ -55,3 +70,18 @@ func (mr *MockTLSManagerInterfaceMockRecorder) EnsureTLS(ctx, tlsInfo any) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureTLS", reflect.TypeOf((*MockTLSManagerInterface)(nil).EnsureTLS), ctx, tlsInfo)
 }
+
+// ListManagedCertificates mocks base method.
+func (m *MockTLSManagerInterface) ListManagedCertificates(ctx context.Context) ([]serverscom.SSLCertificate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListManagedCertificates", ctx)
+	ret0, _ := ret[0].([]serverscom.SSLCertificate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListManagedCertificates indicates an expected call of ListManagedCertificates.
+func (mr *MockTLSManagerInterfaceMockRecorder) ListManagedCertificates(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListManagedCertificates", reflect.TypeOf((*MockTLSManagerInterface)(nil).ListManagedCertificates), ctx)
+}
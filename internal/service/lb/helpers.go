@@ -1,7 +1,13 @@
 package lbsrv
 
 import (
+	"crypto/sha256"
+	"encoding/json"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -9,14 +15,33 @@ import (
 	"github.com/serverscom/api-gateway-controller/internal/types"
 
 	serverscom "github.com/serverscom/serverscom-go-client/pkg"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
-// translateGatewayToLBInput translates gateway based on gateway info and tlsInfo info into LB L7 create input
-func translateGatewayToLBInput(gwInfo *types.GatewayInfo, tlsInfo map[string]string) (*serverscom.L7LoadBalancerCreateInput, error) {
+const (
+	// drainingWeight is weight of draining nodes upstreams, weight 0 is omitted by API client
+	drainingWeight = 1
+	// drainingMaxConns limits connections of draining nodes upstreams
+	drainingMaxConns = 1
+	// maxUpstreamWeight limits upstream weight, it's the maximum weight of HTTPRoute backendRef
+	maxUpstreamWeight = 1000000
+)
+
+// translateGatewayToLBInput translates gateway based on gateway info and tlsInfo info into LB L7 create input,
+// keys define labels of certificates and config hash
+func translateGatewayToLBInput(gwInfo *types.GatewayInfo, tlsInfo map[string]string, keys config.Keys) (*serverscom.L7LoadBalancerCreateInput, error) {
 	upstreamMap := make(map[string]serverscom.L7UpstreamZoneInput)
 	var vhostZones []serverscom.L7VHostZoneInput
 
-	for host, vh := range gwInfo.VHosts {
+	// iterate in stable order to get the same input for the same gateway
+	hosts := make([]string, 0, len(gwInfo.VHosts))
+	for host := range gwInfo.VHosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+
+	for _, host := range hosts {
+		vh := gwInfo.VHosts[host]
 		sslEnabled := vh.SSL
 		sslId := ""
 		if sslEnabled {
@@ -26,25 +51,25 @@ func translateGatewayToLBInput(gwInfo *types.GatewayInfo, tlsInfo map[string]str
 		}
 		locationZones := []serverscom.L7LocationZoneInput{}
 		for _, p := range vh.Paths {
-			upstreamId := fmt.Sprintf("upstream-zone-%s-%d", p.Service.Name, p.NodePort)
-			locationZones = append(locationZones, serverscom.L7LocationZoneInput{
-				Location:   p.Path,
-				UpstreamID: upstreamId,
-			})
+			backends := weightedBackends(p.Backends)
+			if len(backends) == 0 {
+				continue
+			}
+			upstreamId := getUpstreamZoneID(backends)
 			if _, ok := upstreamMap[upstreamId]; !ok {
-				var ups []serverscom.L7UpstreamInput
-				for _, ip := range p.NodeIps {
-					ups = append(ups, serverscom.L7UpstreamInput{
-						IP:     ip,
-						Port:   int32(p.NodePort),
-						Weight: 1,
-					})
-				}
 				upstreamMap[upstreamId] = serverscom.L7UpstreamZoneInput{
 					ID:        upstreamId,
-					Upstreams: ups,
+					Upstreams: zoneUpstreams(backends, p.NodeIps, p.DrainingNodeIps),
 				}
 			}
+			// path without ready pods or nodes can't be served
+			if len(upstreamMap[upstreamId].Upstreams) == 0 {
+				continue
+			}
+			locationZones = append(locationZones, serverscom.L7LocationZoneInput{
+				Location:   locationForPath(p),
+				UpstreamID: upstreamId,
+			})
 		}
 		if len(vh.Ports) == 0 || len(locationZones) == 0 {
 			continue
@@ -61,26 +86,357 @@ func translateGatewayToLBInput(gwInfo *types.GatewayInfo, tlsInfo map[string]str
 
 	var upstreamZones []serverscom.L7UpstreamZoneInput
 	for _, u := range upstreamMap {
-		upstreamZones = append(upstreamZones, u)
+		if len(u.Upstreams) > 0 {
+			upstreamZones = append(upstreamZones, u)
+		}
 	}
+	sort.Slice(upstreamZones, func(i, j int) bool {
+		return upstreamZones[i].ID < upstreamZones[j].ID
+	})
 	if len(vhostZones) == 0 || len(upstreamZones) == 0 {
 		return nil, fmt.Errorf("vhost or upstream can't be empty, can't continue")
 	}
-	locIdStr := config.FetchEnv("SC_LOCATION_ID", "1")
-	locId, err := strconv.Atoi(locIdStr)
-	if err != nil {
-		locId = 1
+	locId := gwInfo.Params.LocationID
+	if locId == 0 {
+		locId = defaultLocationID()
 	}
 	lbInput := &serverscom.L7LoadBalancerCreateInput{
-		Name:          getLoadBalancerName(gwInfo.UID),
-		LocationID:    int64(locId),
-		UpstreamZones: upstreamZones,
-		VHostZones:    vhostZones,
-		Labels: map[string]string{
-			config.GW_LABEL_ID: gwInfo.UID,
-		},
-	}
-	return lbInput, err
+		Name:              getLoadBalancerName(gwInfo.UID),
+		LocationID:        locId,
+		StoreLogs:         gwInfo.Params.StoreLogs,
+		StoreLogsRegionID: gwInfo.Params.StoreLogsRegionID,
+		Geoip:             gwInfo.Params.Geoip,
+		ClusterID:         gwInfo.Params.ClusterID,
+		UpstreamZones:     upstreamZones,
+		VHostZones:        vhostZones,
+		Labels:            maps.Clone(gwInfo.Labels),
+	}
+	if lbInput.Labels == nil {
+		lbInput.Labels = map[string]string{}
+	}
+	// track certificates used by vhosts, labels are updated together with vhosts
+	for _, vh := range vhostZones {
+		if vh.SSLCertID != "" {
+			lbInput.Labels[CertificateLabel(keys, vh.SSLCertID)] = "true"
+		}
+	}
+	hash, err := getLBInputHash(lbInput)
+	if err != nil {
+		return nil, err
+	}
+	lbInput.Labels[keys.ConfigHashLabel()] = hash
+	return lbInput, nil
+}
+
+// zoneUpstreams returns upstreams of zone serving backends.
+// Backend is served by all nodes, by nodes hosting its local endpoints or directly by its pods,
+// traffic share of every backend is kept proportional to its weight regardless of number of its upstreams.
+func zoneUpstreams(backends []types.BackendInfo, nodeIps, drainingIps []string) []serverscom.L7UpstreamInput {
+	nodeIps = slices.Clone(nodeIps)
+	sort.Strings(nodeIps)
+	drainingIps = slices.Clone(drainingIps)
+	sort.Strings(drainingIps)
+
+	groups := make([]weightedUpstreams, 0, len(backends))
+	var draining []serverscom.L7UpstreamInput
+	for _, b := range backends {
+		if b.Direct {
+			groups = append(groups, weightedUpstreams{weight: b.Weight, upstreams: podUpstreams(b)})
+			continue
+		}
+		group := weightedUpstreams{weight: b.Weight}
+		for _, ip := range nodeIps {
+			if count := localEndpoints(b, ip); count > 0 {
+				group.upstreams = append(group.upstreams, serverscom.L7UpstreamInput{
+					IP:     ip,
+					Port:   int32(b.NodePort),
+					Weight: count,
+				})
+			}
+		}
+		groups = append(groups, group)
+		for _, ip := range drainingIps {
+			if count := localEndpoints(b, ip); count > 0 {
+				draining = append(draining, drainingUpstream(ip, b))
+			}
+		}
+	}
+	return append(proportionalWeights(groups), draining...)
+}
+
+// weightedUpstreams contains upstreams of backend, upstream weight is number of backend endpoints behind it
+type weightedUpstreams struct {
+	weight    int32
+	upstreams []serverscom.L7UpstreamInput
+}
+
+// proportionalWeights returns upstreams of all groups with weights giving every group share of traffic
+// proportional to group weight, group share is split among its upstreams by their endpoints.
+// Weights are exact when possible, otherwise they are rounded to fit maxUpstreamWeight,
+// every upstream gets at least weight 1.
+func proportionalWeights(groups []weightedUpstreams) []serverscom.L7UpstreamInput {
+	totals := make([]int64, len(groups))
+	scale := int64(1)
+	for i, g := range groups {
+		for _, u := range g.upstreams {
+			totals[i] += int64(u.Weight)
+		}
+		if totals[i] > 0 && scale <= maxUpstreamWeight {
+			scale = lcm(scale, totals[i])
+		}
+	}
+	scale = min(scale, maxUpstreamWeight)
+
+	var ups []serverscom.L7UpstreamInput
+	var weights []int64
+	for i, g := range groups {
+		for _, u := range g.upstreams {
+			w := int64(math.Round(float64(g.weight) * float64(u.Weight) * float64(scale) / float64(totals[i])))
+			ups = append(ups, u)
+			weights = append(weights, max(w, 1))
+		}
+	}
+
+	var highest int64
+	for _, w := range weights {
+		highest = max(highest, w)
+	}
+	for i, w := range weights {
+		if highest > maxUpstreamWeight {
+			w = max(int64(math.Round(float64(w)*maxUpstreamWeight/float64(highest))), 1)
+		}
+		ups[i].Weight = int32(w)
+	}
+	return ups
+}
+
+func gcd(a, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+func lcm(a, b int64) int64 {
+	return a / gcd(a, b) * b
+}
+
+// drainingUpstream returns upstream of draining node, it keeps in-flight connections
+// but takes the minimal share of new ones limited to single connection.
+// Weights of active upstreams are not changed.
+func drainingUpstream(ip string, b types.BackendInfo) serverscom.L7UpstreamInput {
+	return serverscom.L7UpstreamInput{
+		IP:       ip,
+		Port:     int32(b.NodePort),
+		Weight:   drainingWeight,
+		MaxConns: drainingMaxConns,
+	}
+}
+
+// podUpstreams returns upstreams of backend in Pod upstream mode, every pod is a single endpoint
+func podUpstreams(b types.BackendInfo) []serverscom.L7UpstreamInput {
+	var ups []serverscom.L7UpstreamInput
+	for _, ep := range b.PodEndpoints {
+		ups = append(ups, serverscom.L7UpstreamInput{
+			IP:     ep.IP,
+			Port:   ep.Port,
+			Weight: 1,
+		})
+	}
+	return ups
+}
+
+// localEndpoints returns number of backend endpoints on node, it's 1 for backend served by all nodes
+func localEndpoints(b types.BackendInfo, ip string) int32 {
+	if b.LocalEndpoints == nil {
+		return 1
+	}
+	return b.LocalEndpoints[ip]
+}
+
+// defaultLocationID returns location of LBs from env, it's used if gateway class doesn't set location
+func defaultLocationID() int64 {
+	locId, err := strconv.ParseInt(config.FetchEnv("SC_LOCATION_ID", "1"), 10, 64)
+	if err != nil {
+		return 1
+	}
+	return locId
+}
+
+// defaultClusterID returns dedicated LB cluster from env, nil means shared cluster.
+// It's used for created LBs if neither gateway class nor gateway set cluster.
+func defaultClusterID() *string {
+	if id := config.FetchEnv("SC_CLUSTER_ID"); id != "" {
+		return &id
+	}
+	return nil
+}
+
+// inheritCluster places LB input without cluster set by gateway class or gateway on cluster of existing LB,
+// so changing default cluster never replaces existing LBs. Returns false if cluster is set explicitly.
+func inheritCluster(lbInput *serverscom.L7LoadBalancerCreateInput, lb *serverscom.L7LoadBalancer) bool {
+	if lbInput.ClusterID != nil {
+		return false
+	}
+	lbInput.ClusterID = lb.ClusterID
+	return true
+}
+
+// CertificateLabel returns LB label key which marks certificate as used by LB vhosts
+func CertificateLabel(keys config.Keys, certID string) string {
+	return keys.CertLabelPrefix() + certID
+}
+
+// CertificatesInUse returns IDs of certificates used by vhosts of given load balancers.
+// Certificate labels of keys to migrate from are taken into account until LBs are relabelled.
+func CertificatesInUse(lbs []serverscom.LoadBalancer, keys config.Keys) map[string]bool {
+	prefixes := []string{keys.CertLabelPrefix()}
+	if keys.OldGatewayLabel != "" {
+		prefixes = append(prefixes, keys.Old().CertLabelPrefix())
+	}
+	ids := make(map[string]bool)
+	for _, lb := range lbs {
+		for k := range lb.Labels {
+			for _, prefix := range prefixes {
+				if id, ok := strings.CutPrefix(k, prefix); ok && id != "" {
+					ids[id] = true
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// getLBInputHash returns short hash of LB input.
+// Provider doesn't return zones of existing LB, so hash stored in LB label is used to detect config changes.
+func getLBInputHash(in *serverscom.L7LoadBalancerCreateInput) (string, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal lb input: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return fmt.Sprintf("%x", sum[:8]), nil
+}
+
+// translateCreateToUpdateInput converts LB create input into update input.
+// LB cluster is never changed by update, so SharedCluster is not set.
+func translateCreateToUpdateInput(in *serverscom.L7LoadBalancerCreateInput) serverscom.L7LoadBalancerUpdateInput {
+	out := serverscom.L7LoadBalancerUpdateInput{
+		Name:              in.Name,
+		StoreLogs:         in.StoreLogs,
+		StoreLogsRegionID: in.StoreLogsRegionID,
+		Geoip:             in.Geoip,
+		VHostZones:        in.VHostZones,
+		UpstreamZones:     in.UpstreamZones,
+		ClusterID:         in.ClusterID,
+		Labels:            in.Labels,
+	}
+	return out
+}
+
+// lbNeedsUpdate compares actual LB with desired update input.
+func lbNeedsUpdate(lb *serverscom.L7LoadBalancer, in serverscom.L7LoadBalancerUpdateInput) bool {
+	return len(lbDiff(lb, in)) > 0
+}
+
+// lbDiff returns differences between actual LB and desired update input.
+// Zones are compared through config hash label and domains list since provider doesn't return zones.
+func lbDiff(lb *serverscom.L7LoadBalancer, in serverscom.L7LoadBalancerUpdateInput) []string {
+	var diff []string
+	if lb.Name != in.Name {
+		diff = append(diff, fmt.Sprintf("name %q, expected %q", lb.Name, in.Name))
+	}
+	labelKeys := make([]string, 0, len(in.Labels))
+	for k := range in.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		if lb.Labels[k] != in.Labels[k] {
+			diff = append(diff, fmt.Sprintf("label %s=%q, expected %q", k, lb.Labels[k], in.Labels[k]))
+		}
+	}
+	if in.Geoip != nil && lb.Geoip != *in.Geoip {
+		diff = append(diff, fmt.Sprintf("geoip %t, expected %t", lb.Geoip, *in.Geoip))
+	}
+	if in.StoreLogs != nil && lb.StoreLogs != *in.StoreLogs {
+		diff = append(diff, fmt.Sprintf("store logs %t, expected %t", lb.StoreLogs, *in.StoreLogs))
+	}
+	if in.StoreLogsRegionID != nil && lb.StoreLogsRegionID != int64(*in.StoreLogsRegionID) {
+		diff = append(diff, fmt.Sprintf("store logs region %d, expected %d", lb.StoreLogsRegionID, *in.StoreLogsRegionID))
+	}
+	if actual, desired := clusterName(lb.ClusterID), clusterName(in.ClusterID); actual != desired {
+		diff = append(diff, fmt.Sprintf("cluster %q, expected %q", actual, desired))
+	}
+	var domains []string
+	for _, vh := range in.VHostZones {
+		domains = append(domains, vh.Domains...)
+	}
+	actual := slices.Clone(lb.Domains)
+	sort.Strings(domains)
+	sort.Strings(actual)
+	if !slices.Equal(domains, actual) {
+		diff = append(diff, fmt.Sprintf("domains %v, expected %v", actual, domains))
+	}
+	return diff
+}
+
+// clusterName returns LB cluster id or "shared" if not set
+func clusterName(id *string) string {
+	if id == nil || *id == "" {
+		return "shared"
+	}
+	return *id
+}
+
+// weightedBackends returns backends with non-zero weight.
+// Single backend always gets weight 1 since there is nothing to split.
+func weightedBackends(backends []types.BackendInfo) []types.BackendInfo {
+	var res []types.BackendInfo
+	for _, b := range backends {
+		if b.Weight > 0 {
+			res = append(res, b)
+		}
+	}
+	if len(res) == 1 {
+		res[0].Weight = 1
+	}
+	return res
+}
+
+// getUpstreamZoneID compose upstream zone id from backends.
+// Single backend zone is named by Service and NodePort, or namespaced Service and port in Pod upstream mode,
+// zone with several backends is named by hash of its backends and weights.
+func getUpstreamZoneID(backends []types.BackendInfo) string {
+	if len(backends) == 1 {
+		if backends[0].Direct {
+			return fmt.Sprintf("upstream-zone-%s-%s-pod-%d", backends[0].Service.Namespace, backends[0].Service.Name, backends[0].ServicePort)
+		}
+		return fmt.Sprintf("upstream-zone-%s-%d", backends[0].Service.Name, backends[0].NodePort)
+	}
+	h := sha256.New()
+	for _, b := range backends {
+		if b.Direct {
+			fmt.Fprintf(h, "%s/%s:pod-%d=%d;", b.Service.Namespace, b.Service.Name, b.ServicePort, b.Weight)
+			continue
+		}
+		fmt.Fprintf(h, "%s/%s:%d=%d;", b.Service.Namespace, b.Service.Name, b.NodePort, b.Weight)
+	}
+	return fmt.Sprintf("upstream-zone-%x", h.Sum(nil)[:8])
+}
+
+// locationForPath translates path match into LB location syntax:
+// prefix as is, "= path" for exact match and `~ "regex"` for regular expression,
+// regex is quoted since it may contain braces of {n,m} quantifiers
+func locationForPath(p types.PathInfo) string {
+	switch p.PathType {
+	case gatewayv1.PathMatchExact:
+		return "= " + p.Path
+	case gatewayv1.PathMatchRegularExpression:
+		return `~ "` + p.Path + `"`
+	default:
+		return p.Path
+	}
 }
 
 // GetLoadBalancerName compose a load balancer name from uid
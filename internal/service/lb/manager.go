@@ -2,8 +2,14 @@ package lbsrv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/serverscom/api-gateway-controller/internal/config"
 	"github.com/serverscom/api-gateway-controller/internal/types"
@@ -16,88 +22,657 @@ import (
 
 type LBManagerInterface interface {
 	EnsureLB(ctx context.Context, gwInfo *types.GatewayInfo, hostCertMap map[string]string) (*serverscom.L7LoadBalancer, error)
-	DeleteLB(ctx context.Context, labelSelector string) error
+	DeleteLB(ctx context.Context, gatewayUID string) error
+	RetainLB(ctx context.Context, gatewayUID string) error
+	DetectDrift(ctx context.Context, gwInfo *types.GatewayInfo, hostCertMap map[string]string) ([]string, error)
+	ListManagedLBs(ctx context.Context) ([]serverscom.LoadBalancer, error)
+	DeleteLBByID(ctx context.Context, id string) error
 }
 
 type Manager struct {
-	scCli *serverscom.Client
+	scCli          *serverscom.Client
+	clusterID      string
+	keys           config.Keys
+	replaceOverlap time.Duration
+	replaceTimeout time.Duration
+	// driftReportOnly disables repairing of LB changed outside of controller
+	driftReportOnly bool
+
+	// activeSince contains time when replacement LB was found active for the first time
+	activeSince map[string]time.Time
+	// written contains state of LBs last written by controller by LB ID
+	written map[string]writtenLB
+	mu      sync.Mutex
+	now     func() time.Time
+}
+
+// writtenLB is state of LB after its last write by controller.
+// Provider doesn't return zones of LB, so zones changed out-of-band are detected by LB update time,
+// which is taken when LB with written config hash is seen for the first time.
+type writtenLB struct {
+	hash    string
+	updated time.Time
+	// observed is set if LB wasn't written by this controller instance and was taken as is
+	observed bool
 }
 
-func NewManager(c *serverscom.Client) *Manager {
-	return &Manager{scCli: c}
+// NewManager creates LB manager, clusterID is stamped on created LBs to distinguish clusters sharing one account.
+// keys.GatewayLabel marks LBs managed by this controller instance.
+// replaceOverlap is time both old and new LBs are kept after replacement LB becomes active,
+// replaceTimeout is time replacement LB should become active in, 0 means no timeout.
+// If driftReportOnly is set, LB changed outside of controller is updated only when desired config changes.
+func NewManager(c *serverscom.Client, clusterID string, keys config.Keys, replaceOverlap, replaceTimeout time.Duration, driftReportOnly bool) *Manager {
+	return &Manager{
+		scCli:           c,
+		clusterID:       clusterID,
+		keys:            keys,
+		replaceOverlap:  replaceOverlap,
+		replaceTimeout:  replaceTimeout,
+		driftReportOnly: driftReportOnly,
+		activeSince:     make(map[string]time.Time),
+		written:         make(map[string]writtenLB),
+		now:             time.Now,
+	}
 }
 
 // EnsureLB ensures a load balancer exists for the given GatewayInfo.
 // It creates, updates, or returns existing LB status.
+// If immutable settings changed, replacement LB is created next to existing one, see ensureReplacement.
 // hostCertMap contains external cert id for specific hosts.
 func (s *Manager) EnsureLB(ctx context.Context, gwInfo *types.GatewayInfo, hostCertMap map[string]string) (*serverscom.L7LoadBalancer, error) {
-	labelSelector := config.GW_LABEL_ID + "=" + gwInfo.UID
-	lbs, err := s.getL7LoadBalancersByLabel(ctx, labelSelector)
+	lbs, _, err := s.findGatewayLBs(ctx, gwInfo.UID)
 	if err != nil {
 		return nil, err
 	}
+	if len(lbs) == 0 && gwInfo.AdoptLBID != "" {
+		return s.adoptLB(ctx, gwInfo, hostCertMap)
+	}
 	if len(lbs) == 0 {
 		// create lb
-		lbInput, err := translateGatewayToLBInput(gwInfo, hostCertMap)
+		lbInput, err := s.buildLBInput(gwInfo, hostCertMap)
+		if err != nil {
+			return nil, err
+		}
+		if lbInput.ClusterID == nil {
+			lbInput.ClusterID = defaultClusterID()
+		}
+		if err := s.validateCluster(ctx, lbInput); err != nil {
+			return nil, err
+		}
+		lb, err := s.scCli.LoadBalancers.CreateL7LoadBalancer(ctx, *lbInput)
 		if err != nil {
 			return nil, err
 		}
-		return s.scCli.LoadBalancers.CreateL7LoadBalancer(ctx, *lbInput)
+		s.recordWritten(lb.ID, lbInput)
+		return lb, nil
 	}
-	if len(lbs) > 1 {
-		return nil, fmt.Errorf("found more than one lb with same label")
+	lb, replacement, err := splitReplacement(lbs, s.keys)
+	if err != nil {
+		return nil, err
+	}
+	if gwInfo.AdoptLBID != "" && gwInfo.AdoptLBID != lb.ID {
+		return nil, fmt.Errorf("can't adopt load balancer %q, gateway already has load balancer %q", gwInfo.AdoptLBID, lb.ID)
+	}
+	if replacement != nil {
+		return s.ensureReplacement(ctx, gwInfo, hostCertMap, lb, *replacement)
+	}
+	lbInput, err := s.buildLBInput(gwInfo, hostCertMap)
+	if err != nil {
+		return nil, err
 	}
+	return s.updateLB(ctx, lb, lbInput)
+}
+
+// updateLB updates existing LB of the gateway, or starts its replacement if immutable settings changed.
+// Replacement to the same location and cluster which already timed out is not retried.
+func (s *Manager) updateLB(ctx context.Context, lb serverscom.LoadBalancer, lbInput *serverscom.L7LoadBalancerCreateInput) (*serverscom.L7LoadBalancer, error) {
 	// if not active yet, just return status to reconcile again
-	lb := lbs[0]
 	if !strings.EqualFold(lb.Status, config.LB_ACTIVE_STATUS) {
 		lbl7 := &serverscom.L7LoadBalancer{
 			Status: lb.Status,
 		}
 		return lbl7, nil
 	}
-	// update lb
-	lbInput, err := translateGatewayToLBInput(gwInfo, hostCertMap)
+	// skip update if nothing changed, LB found by old label key always differs in labels
+	current, err := s.scCli.LoadBalancers.GetL7LoadBalancer(ctx, lb.ID)
+	if err != nil {
+		return nil, err
+	}
+	inherited := inheritCluster(lbInput, current)
+	if err := checkImmutableUnchanged(current, lbInput); err != nil {
+		// LB moved to another location is placed on default cluster unless gateway sets one
+		if inherited {
+			lbInput.ClusterID = defaultClusterID()
+		}
+		if target := replacementTarget(lbInput.LocationID, lbInput.ClusterID); lb.Labels[s.keys.ReplaceFailedLabel()] == target {
+			return nil, &utils.ReplacementTimeoutError{Name: lb.Name, Target: target, Timeout: s.replaceTimeout}
+		}
+		return s.createReplacement(ctx, lbInput, lb)
+	}
+	// update also drops mark of timed out replacement, since immutable settings were reverted,
+	// and repairs zones changed out-of-band
+	_, replaceFailed := current.Labels[s.keys.ReplaceFailedLabel()]
+	if replaceFailed || configChanged(current, lbInput, s.keys) {
+		return s.writeLB(ctx, lb.ID, lbInput)
+	}
+	modified := s.modifiedOutside(current)
+	if s.driftReportOnly || (!modified && !lbNeedsUpdate(current, translateCreateToUpdateInput(lbInput))) {
+		return current, nil
+	}
+
+	return s.writeLB(ctx, lb.ID, lbInput)
+}
+
+// adoptLB takes existing L7 load balancer under management, it is updated with gateway config and labels.
+// External addresses of adopted LB are preserved. LB owned by another gateway or cluster is never adopted.
+func (s *Manager) adoptLB(ctx context.Context, gwInfo *types.GatewayInfo, hostCertMap map[string]string) (*serverscom.L7LoadBalancer, error) {
+	lb, err := s.scCli.LoadBalancers.GetL7LoadBalancer(ctx, gwInfo.AdoptLBID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get load balancer %q to adopt: %w", gwInfo.AdoptLBID, err)
+	}
+	if !strings.EqualFold(lb.Type, config.LB_L7_TYPE) {
+		return nil, fmt.Errorf("can't adopt load balancer %q of type %q, only l7 is supported", lb.ID, lb.Type)
+	}
+	for _, key := range []string{s.keys.GatewayLabel, s.keys.OldGatewayLabel} {
+		if owner := lb.Labels[key]; key != "" && owner != "" && owner != gwInfo.UID {
+			return nil, fmt.Errorf("can't adopt load balancer %q, it is owned by another gateway %q", lb.ID, owner)
+		}
+	}
+	if !utils.BelongsToCluster(lb.Labels, s.keys, s.clusterID) {
+		return nil, &utils.ClusterConflictError{
+			Kind:      "load balancer",
+			Name:      lb.Name,
+			ClusterID: utils.ClusterOwner(lb.Labels, s.keys),
+		}
+	}
+	if !strings.EqualFold(lb.Status, config.LB_ACTIVE_STATUS) {
+		return &serverscom.L7LoadBalancer{Status: lb.Status}, nil
+	}
+	lbInput, err := s.buildLBInput(gwInfo, hostCertMap)
+	if err != nil {
+		return nil, err
+	}
+	inheritCluster(lbInput, lb)
+	if err := checkImmutableUnchanged(lb, lbInput); err != nil {
+		return nil, err
+	}
+	return s.writeLB(ctx, lb.ID, lbInput)
+}
+
+// createReplacement creates LB with changed immutable settings, it replaces existing LB of the gateway.
+// Replacement LB is labelled with ID of replaced LB, which keeps serving until replacement is active.
+func (s *Manager) createReplacement(ctx context.Context, lbInput *serverscom.L7LoadBalancerCreateInput, old serverscom.LoadBalancer) (*serverscom.L7LoadBalancer, error) {
+	if err := s.validateCluster(ctx, lbInput); err != nil {
+		return nil, err
+	}
+	lbInput.Labels[s.keys.ReplacesLabel()] = old.ID
+	if _, err := s.scCli.LoadBalancers.CreateL7LoadBalancer(ctx, *lbInput); err != nil {
+		return nil, fmt.Errorf("failed to create replacement of load balancer %q: %w", old.ID, err)
+	}
+	return replacingLB(old, nil, s.keys), nil
+}
+
+// ensureReplacement syncs replacement LB and deletes replaced one.
+// Replaced LB is deleted when replacement is active for replaceOverlap, addresses of both LBs are returned until then.
+// Replacement is cancelled if immutable settings are reverted to ones of replaced LB,
+// or if it doesn't become active in replaceTimeout.
+// Replaced LB is not updated, other changes of immutable settings are refused until replacement is finished.
+func (s *Manager) ensureReplacement(
+	ctx context.Context,
+	gwInfo *types.GatewayInfo,
+	hostCertMap map[string]string,
+	old, replacement serverscom.LoadBalancer,
+) (*serverscom.L7LoadBalancer, error) {
+	lbInput, err := s.buildLBInput(gwInfo, hostCertMap)
+	if err != nil {
+		return nil, err
+	}
+	if immutableMatches(old, lbInput) {
+		if err := s.cancelReplacement(ctx, replacement); err != nil {
+			return nil, err
+		}
+		return s.updateLB(ctx, old, lbInput)
+	}
+	if !strings.EqualFold(replacement.Status, config.LB_ACTIVE_STATUS) {
+		if s.replaceTimeout > 0 && s.now().Sub(replacement.Created) > s.replaceTimeout {
+			return nil, s.abortReplacement(ctx, old, replacement)
+		}
+		return replacingLB(old, nil, s.keys), nil
+	}
+	current, err := s.scCli.LoadBalancers.GetL7LoadBalancer(ctx, replacement.ID)
+	if err != nil {
+		return nil, err
+	}
+	inheritCluster(lbInput, current)
+	if err := checkImmutableUnchanged(current, lbInput); err != nil {
+		return nil, fmt.Errorf("load balancer %q is being replaced: %w", old.ID, err)
+	}
+
+	if s.now().Sub(s.markActive(replacement.ID)) < s.replaceOverlap {
+		lbInput.Labels[s.keys.ReplacesLabel()] = old.ID
+		lbUpdateInput := translateCreateToUpdateInput(lbInput)
+		if lbNeedsUpdate(current, lbUpdateInput) {
+			if current, err = s.scCli.LoadBalancers.UpdateL7LoadBalancer(ctx, replacement.ID, lbUpdateInput); err != nil {
+				return nil, err
+			}
+		}
+		return replacingLB(old, current, s.keys), nil
+	}
+
+	// overlap is over, replacement becomes regular LB of the gateway
+	if err := utils.IgnoreNotFound(s.scCli.LoadBalancers.DeleteL7LoadBalancer(ctx, old.ID)); err != nil {
+		return nil, fmt.Errorf("failed to delete replaced load balancer %q: %w", old.ID, err)
+	}
+	lb, err := s.writeLB(ctx, replacement.ID, lbInput)
 	if err != nil {
 		return nil, err
 	}
+	s.forgetActive(replacement.ID)
+	s.forgetWritten(old.ID)
+	return lb, nil
+}
+
+// cancelReplacement deletes replacement LB, replaced LB stays the LB of the gateway
+func (s *Manager) cancelReplacement(ctx context.Context, replacement serverscom.LoadBalancer) error {
+	if err := utils.IgnoreNotFound(s.scCli.LoadBalancers.DeleteL7LoadBalancer(ctx, replacement.ID)); err != nil {
+		return fmt.Errorf("failed to cancel replacement load balancer %q: %w", replacement.ID, err)
+	}
+	s.forgetActive(replacement.ID)
+	return nil
+}
+
+// abortReplacement cancels replacement which didn't become active in time.
+// Replaced LB is labelled with replacement location and cluster, so the same replacement is not retried
+// until immutable settings change again. Returns ReplacementTimeoutError.
+func (s *Manager) abortReplacement(ctx context.Context, old, replacement serverscom.LoadBalancer) error {
+	target := replacementTarget(replacement.LocationID, replacement.ClusterID)
+	labels := maps.Clone(old.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[s.keys.ReplaceFailedLabel()] = target
+	if _, err := s.scCli.LoadBalancers.UpdateL7LoadBalancer(ctx, old.ID, serverscom.L7LoadBalancerUpdateInput{Labels: labels}); err != nil {
+		return fmt.Errorf("failed to label replaced load balancer %q: %w", old.ID, err)
+	}
+	// label update isn't a change of config, next update time is taken as baseline
+	s.forgetWritten(old.ID)
+	if err := s.cancelReplacement(ctx, replacement); err != nil {
+		return err
+	}
+	return &utils.ReplacementTimeoutError{Name: old.Name, Target: target, Timeout: s.replaceTimeout}
+}
+
+// markActive returns time when LB was found active for the first time
+func (s *Manager) markActive(id string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	since, ok := s.activeSince[id]
+	if !ok {
+		since = s.now()
+		s.activeSince[id] = since
+	}
+	return since
+}
+
+func (s *Manager) forgetActive(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.activeSince, id)
+}
+
+// writeLB updates LB with full config of LB input and records written config
+func (s *Manager) writeLB(ctx context.Context, id string, lbInput *serverscom.L7LoadBalancerCreateInput) (*serverscom.L7LoadBalancer, error) {
+	lb, err := s.scCli.LoadBalancers.UpdateL7LoadBalancer(ctx, id, translateCreateToUpdateInput(lbInput))
+	if err != nil {
+		return nil, err
+	}
+	s.recordWritten(id, lbInput)
+	return lb, nil
+}
+
+// recordWritten remembers config hash written to LB, its update time is taken when LB is seen next time
+func (s *Manager) recordWritten(id string, lbInput *serverscom.L7LoadBalancerCreateInput) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written[id] = writtenLB{hash: lbInput.Labels[s.keys.ConfigHashLabel()]}
+}
+
+func (s *Manager) forgetWritten(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.written, id)
+}
+
+// modifiedOutside returns true if LB was changed by someone else since controller wrote it:
+// its config hash label differs from written one or it was updated after controller had seen it with written config.
+// LB not written by this controller instance, e.g. after restart, is taken as is.
+func (s *Manager) modifiedOutside(lb *serverscom.L7LoadBalancer) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hash := lb.Labels[s.keys.ConfigHashLabel()]
+	state, ok := s.written[lb.ID]
+	if !ok {
+		s.written[lb.ID] = writtenLB{hash: hash, updated: lb.Updated, observed: true}
+		return false
+	}
+	if state.hash != hash {
+		return true
+	}
+	if state.updated.IsZero() {
+		state.updated = lb.Updated
+		s.written[lb.ID] = state
+		return false
+	}
+	return !lb.Updated.Equal(state.updated)
+}
+
+// configChanged returns true if desired config differs from one last written to LB, i.e. its config hash label
+func configChanged(lb *serverscom.L7LoadBalancer, lbInput *serverscom.L7LoadBalancerCreateInput, keys config.Keys) bool {
+	return lb.Labels[keys.ConfigHashLabel()] != lbInput.Labels[keys.ConfigHashLabel()]
+}
+
+// validateCluster checks that dedicated LB cluster of the input exists in account and serves LB location
+func (s *Manager) validateCluster(ctx context.Context, lbInput *serverscom.L7LoadBalancerCreateInput) error {
+	if lbInput.ClusterID == nil {
+		return nil
+	}
+	cluster, err := s.scCli.LoadBalancerClusters.GetLoadBalancerCluster(ctx, *lbInput.ClusterID)
+	if err != nil {
+		var notFoundErr *serverscom.NotFoundError
+		if errors.As(err, &notFoundErr) {
+			return fmt.Errorf("load balancer cluster %q not found", *lbInput.ClusterID)
+		}
+		return fmt.Errorf("failed to get load balancer cluster %q: %w", *lbInput.ClusterID, err)
+	}
+	if cluster.LocationID != lbInput.LocationID {
+		return fmt.Errorf("load balancer cluster %q is in location %d, expected %d", cluster.ID, cluster.LocationID, lbInput.LocationID)
+	}
+	return nil
+}
+
+// checkImmutableUnchanged returns ImmutableChangeError if existing LB differs from desired in settings
+// which can't be updated: location and cluster. Such LB has to be replaced.
+func checkImmutableUnchanged(lb *serverscom.L7LoadBalancer, lbInput *serverscom.L7LoadBalancerCreateInput) error {
+	if lb.LocationID != lbInput.LocationID {
+		return &utils.ImmutableChangeError{
+			Name:    lb.Name,
+			Field:   "location",
+			Current: strconv.FormatInt(lb.LocationID, 10),
+			Desired: strconv.FormatInt(lbInput.LocationID, 10),
+		}
+	}
+	if current, desired := clusterName(lb.ClusterID), clusterName(lbInput.ClusterID); current != desired {
+		return &utils.ImmutableChangeError{Name: lb.Name, Field: "cluster", Current: current, Desired: desired}
+	}
+	return nil
+}
+
+// immutableMatches returns true if LB has location and cluster of LB input.
+// Input without cluster matches LB on any cluster.
+func immutableMatches(lb serverscom.LoadBalancer, lbInput *serverscom.L7LoadBalancerCreateInput) bool {
+	current := &serverscom.L7LoadBalancer{Name: lb.Name, LocationID: lb.LocationID, ClusterID: lb.ClusterID}
+	in := *lbInput
+	inheritCluster(&in, current)
+	return checkImmutableUnchanged(current, &in) == nil
+}
+
+// replacementTarget returns label value identifying location and cluster of replacement LB
+func replacementTarget(locationID int64, clusterID *string) string {
+	return fmt.Sprintf("%d-%s", locationID, clusterName(clusterID))
+}
+
+// splitReplacement returns LB of the gateway and its replacement if replacement is in progress.
+// Replacement left after replaced LB was deleted is returned as regular LB, next update removes its label.
+func splitReplacement(lbs []serverscom.LoadBalancer, keys config.Keys) (serverscom.LoadBalancer, *serverscom.LoadBalancer, error) {
+	var primary, replacements []serverscom.LoadBalancer
+	for _, lb := range lbs {
+		if replacedLBID(lb.Labels, keys) != "" {
+			replacements = append(replacements, lb)
+		} else {
+			primary = append(primary, lb)
+		}
+	}
+	switch {
+	case len(primary) == 1 && len(replacements) == 0:
+		return primary[0], nil, nil
+	case len(primary) == 1 && len(replacements) == 1 && replacedLBID(replacements[0].Labels, keys) == primary[0].ID:
+		return primary[0], &replacements[0], nil
+	case len(primary) == 0 && len(replacements) == 1:
+		return replacements[0], nil, nil
+	}
+	return serverscom.LoadBalancer{}, nil, fmt.Errorf("found more than one lb with same label")
+}
 
-	lbUpdateInput := serverscom.L7LoadBalancerUpdateInput{
-		Name:              lbInput.Name,
-		StoreLogs:         lbInput.StoreLogs,
-		StoreLogsRegionID: lbInput.StoreLogsRegionID,
-		Geoip:             lbInput.Geoip,
-		VHostZones:        lbInput.VHostZones,
-		UpstreamZones:     lbInput.UpstreamZones,
-		ClusterID:         lbInput.ClusterID,
+// replacingLB returns gateway LB state during replacement.
+// Replaced LB keeps serving until replacement is active, then addresses of both are returned.
+func replacingLB(old serverscom.LoadBalancer, replacement *serverscom.L7LoadBalancer, keys config.Keys) *serverscom.L7LoadBalancer {
+	if replacement == nil {
+		return &serverscom.L7LoadBalancer{
+			ID:                old.ID,
+			Name:              old.Name,
+			Status:            old.Status,
+			ExternalAddresses: slices.Clone(old.ExternalAddresses),
+			Labels:            map[string]string{keys.ReplacesLabel(): old.ID},
+		}
 	}
-	if lbUpdateInput.ClusterID == nil {
-		lbUpdateInput.SharedCluster = utils.BoolPtr(true)
+	lb := *replacement
+	lb.ExternalAddresses = append(slices.Clone(replacement.ExternalAddresses), old.ExternalAddresses...)
+	lb.Labels = maps.Clone(replacement.Labels)
+	if lb.Labels == nil {
+		lb.Labels = map[string]string{}
 	}
+	lb.Labels[keys.ReplacesLabel()] = old.ID
+	return &lb
+}
+
+// ReplacementInProgress returns true if LB returned by EnsureLB is being replaced,
+// EnsureLB has to be called again to finish replacement.
+func ReplacementInProgress(lb *serverscom.L7LoadBalancer, keys config.Keys) bool {
+	return lb.Labels[keys.ReplacesLabel()] != ""
+}
 
-	return s.scCli.LoadBalancers.UpdateL7LoadBalancer(ctx, lb.ID, lbUpdateInput)
+// replacedLBID returns ID of LB replaced by LB with given labels.
+// LB found by old keys is labelled with replaces label of old keys.
+func replacedLBID(labels map[string]string, keys config.Keys) string {
+	if id := labels[keys.ReplacesLabel()]; id != "" || keys.OldGatewayLabel == "" {
+		return id
+	}
+	return labels[keys.Old().ReplacesLabel()]
 }
 
-// DeleteLB deletes a load balancer by its label selector.
-// Returns error if multiple LBs are found.
-func (s *Manager) DeleteLB(ctx context.Context, labelSelector string) error {
-	lbs, err := s.getL7LoadBalancersByLabel(ctx, labelSelector)
+// DetectDrift compares existing load balancer with desired state and returns found differences.
+// Missing LB is reported as drift. Provider doesn't return zones, so besides visible settings
+// LB changed since controller wrote it is reported, see modifiedOutside.
+// LB with config hash different from desired one is outdated rather than drifted, e.g. gateway changed
+// or controller restarted before update, only changes made outside of controller are reported for it,
+// EnsureLB updates it anyway. LB found by old label key is not checked.
+func (s *Manager) DetectDrift(ctx context.Context, gwInfo *types.GatewayInfo, hostCertMap map[string]string) ([]string, error) {
+	lbs, migrating, err := s.findGatewayLBs(ctx, gwInfo.UID)
+	if err != nil {
+		return nil, err
+	}
+	if migrating {
+		return nil, nil
+	}
+	if len(lbs) == 0 {
+		return []string{"load balancer not found"}, nil
+	}
+	lb, replacement, err := splitReplacement(lbs, s.keys)
+	if err != nil {
+		return nil, err
+	}
+	if replacement != nil || replacedLBID(lb.Labels, s.keys) != "" {
+		// replacement is synced by EnsureLB
+		return nil, nil
+	}
+	if !strings.EqualFold(lb.Status, config.LB_ACTIVE_STATUS) {
+		return nil, nil
+	}
+	lbInput, err := s.buildLBInput(gwInfo, hostCertMap)
 	if err != nil {
+		return nil, err
+	}
+	current, err := s.scCli.LoadBalancers.GetL7LoadBalancer(ctx, lb.ID)
+	if err != nil {
+		return nil, err
+	}
+	var diff []string
+	if s.modifiedOutside(current) {
+		diff = append(diff, fmt.Sprintf("modified outside of controller at %s", current.Updated.Format(time.RFC3339)))
+	}
+	if configChanged(current, lbInput, s.keys) {
+		return diff, nil
+	}
+	inheritCluster(lbInput, current)
+	return append(diff, lbDiff(current, translateCreateToUpdateInput(lbInput))...), nil
+}
+
+// DeleteLB deletes a load balancer of the gateway together with its replacement.
+// Returns error if multiple LBs are found. LBs of other clusters are never deleted.
+func (s *Manager) DeleteLB(ctx context.Context, gatewayUID string) error {
+	lbs, _, err := s.findGatewayLBs(ctx, gatewayUID)
+	if err := utils.IgnoreClusterConflict(err); err != nil {
 		return utils.IgnoreNotFound(err)
 	}
 	if len(lbs) == 0 {
 		// consider as already deleted
 		return nil
 	}
-	if len(lbs) > 1 {
-		return fmt.Errorf("found more than one lb with same label")
+	lb, replacement, err := splitReplacement(lbs, s.keys)
+	if err != nil {
+		return err
+	}
+	if replacement != nil {
+		if err := utils.IgnoreNotFound(s.scCli.LoadBalancers.DeleteL7LoadBalancer(ctx, replacement.ID)); err != nil {
+			return err
+		}
+		s.forgetActive(replacement.ID)
+	}
+	s.forgetWritten(lb.ID)
+	return s.scCli.LoadBalancers.DeleteL7LoadBalancer(ctx, lb.ID)
+}
+
+// RetainLB releases load balancer of the gateway from management without deleting it.
+// Gateway label is replaced by retained label, so LB is ignored by orphans sweeper and can be adopted later.
+// Certificate labels are kept, certificates used by retained LB are not deleted.
+// If LB is being replaced, both LBs are retained.
+func (s *Manager) RetainLB(ctx context.Context, gatewayUID string) error {
+	lbs, _, err := s.findGatewayLBs(ctx, gatewayUID)
+	if err := utils.IgnoreClusterConflict(err); err != nil {
+		return utils.IgnoreNotFound(err)
+	}
+	if len(lbs) == 0 {
+		return nil
+	}
+	if _, _, err := splitReplacement(lbs, s.keys); err != nil {
+		return err
+	}
+	for _, lb := range lbs {
+		labels := make(map[string]string, len(lb.Labels))
+		for k, v := range lb.Labels {
+			switch k {
+			case s.keys.GatewayLabel, s.keys.ConfigHashLabel(), s.keys.ReplacesLabel():
+				continue
+			}
+			if old := s.keys.Old(); old.GatewayLabel != "" {
+				switch k {
+				case old.GatewayLabel, old.ConfigHashLabel(), old.ReplacesLabel():
+					continue
+				}
+			}
+			labels[k] = v
+		}
+		labels[s.keys.RetainedLabel()] = gatewayUID
+		if _, err := s.scCli.LoadBalancers.UpdateL7LoadBalancer(ctx, lb.ID, serverscom.L7LoadBalancerUpdateInput{Labels: labels}); err != nil {
+			return err
+		}
+		s.forgetActive(lb.ID)
+		s.forgetWritten(lb.ID)
+	}
+	return nil
+}
+
+// ListManagedLBs returns all L7 load balancers of this cluster labelled as managed by controller or retained.
+// Retained LBs have no gateway label. LBs labelled with keys to migrate from are returned too,
+// so certificates used by them are not released until they are relabelled.
+func (s *Manager) ListManagedLBs(ctx context.Context) ([]serverscom.LoadBalancer, error) {
+	selectors := []string{s.keys.GatewayLabel, s.keys.RetainedLabel()}
+	if s.keys.OldGatewayLabel != "" {
+		selectors = append(selectors, s.keys.OldGatewayLabel, s.keys.Old().RetainedLabel())
+	}
+	var res []serverscom.LoadBalancer
+	seen := make(map[string]bool)
+	for _, labelSelector := range selectors {
+		lbs, err := s.getL7LoadBalancersByLabel(ctx, labelSelector)
+		if err := utils.IgnoreClusterConflict(err); err != nil {
+			if err := utils.IgnoreNotFound(err); err != nil {
+				return nil, err
+			}
+		}
+		for _, lb := range lbs {
+			if !seen[lb.ID] {
+				seen[lb.ID] = true
+				res = append(res, lb)
+			}
+		}
+	}
+	return res, nil
+}
+
+// DeleteLBByID deletes a load balancer by its ID.
+func (s *Manager) DeleteLBByID(ctx context.Context, id string) error {
+	return utils.IgnoreNotFound(s.scCli.LoadBalancers.DeleteL7LoadBalancer(ctx, id))
+}
+
+// findGatewayLBs returns LBs of the gateway.
+// If old gateway label key configured and nothing found by current key, LBs are searched by old key,
+// migrating is true in this case and LB labels will be replaced by next update.
+func (s *Manager) findGatewayLBs(ctx context.Context, gatewayUID string) (lbs []serverscom.LoadBalancer, migrating bool, err error) {
+	lbs, err = s.getL7LoadBalancersByLabel(ctx, s.keys.GatewayLabel+"="+gatewayUID)
+	if err != nil || len(lbs) > 0 || s.keys.OldGatewayLabel == "" {
+		return lbs, false, err
 	}
-	return s.scCli.LoadBalancers.DeleteL7LoadBalancer(ctx, lbs[0].ID)
+	lbs, err = s.getL7LoadBalancersByLabel(ctx, s.keys.OldGatewayLabel+"="+gatewayUID)
+	return lbs, len(lbs) > 0, err
 }
 
-// getL7LoadBalancersByLabel retrieves all L7 load balancers from provider filtered by label selector.
+// buildLBInput translates gateway into LB input and stamps it with gateway and cluster labels.
+func (s *Manager) buildLBInput(gwInfo *types.GatewayInfo, hostCertMap map[string]string) (*serverscom.L7LoadBalancerCreateInput, error) {
+	lbInput, err := translateGatewayToLBInput(gwInfo, hostCertMap, s.keys)
+	if err != nil {
+		return nil, err
+	}
+	lbInput.Labels[s.keys.GatewayLabel] = gwInfo.UID
+	if s.clusterID != "" {
+		lbInput.Labels[s.keys.ClusterLabel()] = s.clusterID
+	}
+	return lbInput, nil
+}
+
+// getL7LoadBalancersByLabel retrieves L7 load balancers of this cluster from provider filtered by label selector.
+// If some of matched LBs belong to another cluster, LBs of this cluster returned together with ClusterConflictError.
 func (s *Manager) getL7LoadBalancersByLabel(ctx context.Context, labelSelector string) ([]serverscom.LoadBalancer, error) {
-	return s.scCli.LoadBalancers.Collection().
+	lbs, err := s.scCli.LoadBalancers.Collection().
 		SetParam("type", "l7").
 		SetParam("label_selector", labelSelector).
 		Collect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var owned []serverscom.LoadBalancer
+	var conflictErr error
+	for _, lb := range lbs {
+		if utils.BelongsToCluster(lb.Labels, s.keys, s.clusterID) {
+			owned = append(owned, lb)
+			continue
+		}
+		if conflictErr == nil {
+			conflictErr = &utils.ClusterConflictError{
+				Kind:      "load balancer",
+				Name:      lb.Name,
+				ClusterID: utils.ClusterOwner(lb.Labels, s.keys),
+			}
+		}
+	}
+	return owned, conflictErr
 }
@@ -3,16 +3,20 @@ package lbsrv
 import (
 	"context"
 	"errors"
+	"maps"
 	"testing"
+	"time"
 
 	. "github.com/onsi/gomega"
 	serverscom "github.com/serverscom/serverscom-go-client/pkg"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 
 	"github.com/serverscom/api-gateway-controller/internal/config"
 	"github.com/serverscom/api-gateway-controller/internal/mocks"
 	"github.com/serverscom/api-gateway-controller/internal/types"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
 
 	"go.uber.org/mock/gomock"
 )
@@ -26,7 +30,7 @@ func TestEnsureLB(t *testing.T) {
 
 	client := serverscom.NewClientWithEndpoint("", "")
 	client.LoadBalancers = lbHandler
-	manager := NewManager(client)
+	manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
 
 	gwInfo := &types.GatewayInfo{
 		UID:  "gw-uid",
@@ -42,13 +46,12 @@ func TestEnsureLB(t *testing.T) {
 				Paths: []types.PathInfo{
 					{
 						Path: "/",
-						Service: &corev1.Service{
-							ObjectMeta: metav1.ObjectMeta{
-								Name: "svc",
-							},
-						},
-						NodePort: 8080,
-						NodeIps:  []string{"1.1.1.1"},
+						Backends: []types.BackendInfo{{
+							Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+							NodePort: 8080,
+							Weight:   1,
+						}},
+						NodeIps: []string{"1.1.1.1"},
 					},
 				},
 			},
@@ -154,9 +157,50 @@ func TestEnsureLB(t *testing.T) {
 						{ID: "lb1", Status: config.LB_ACTIVE_STATUS},
 					}, nil)
 
+				lbHandler.EXPECT().
+					GetL7LoadBalancer(gomock.Any(), "lb1").
+					Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1}, nil)
 				lbHandler.EXPECT().
 					UpdateL7LoadBalancer(gomock.Any(), "lb1", gomock.Any()).
-					Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS}, nil)
+					DoAndReturn(func(_ context.Context, _ string, in serverscom.L7LoadBalancerUpdateInput) (*serverscom.L7LoadBalancer, error) {
+						g := NewWithT(t)
+						g.Expect(in.Labels).To(HaveKey(config.LB_CONFIG_HASH_LABEL))
+						g.Expect(in.SharedCluster).To(BeNil())
+						g.Expect(in.ClusterID).To(BeNil())
+						return &serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS}, nil
+					})
+			},
+			wantID:     "lb1",
+			wantStatus: config.LB_ACTIVE_STATUS,
+		},
+		{
+			name: "skip update of unchanged lb",
+			setupMocks: func() {
+				lbHandler.EXPECT().
+					Collection().
+					Return(collectionHandler)
+				collectionHandler.EXPECT().
+					SetParam(gomock.Any(), gomock.Any()).
+					AnyTimes().
+					Return(collectionHandler)
+				collectionHandler.EXPECT().
+					Collect(gomock.Any()).
+					Return([]serverscom.LoadBalancer{
+						{ID: "lb1", Status: config.LB_ACTIVE_STATUS},
+					}, nil)
+
+				lbInput, err := manager.buildLBInput(gwInfo, map[string]string{"example.com": "cert-id"})
+				NewWithT(t).Expect(err).To(BeNil())
+				lbHandler.EXPECT().
+					GetL7LoadBalancer(gomock.Any(), "lb1").
+					Return(&serverscom.L7LoadBalancer{
+						ID:         "lb1",
+						Name:       lbInput.Name,
+						Status:     config.LB_ACTIVE_STATUS,
+						LocationID: 1,
+						Domains:    []string{"example.com"},
+						Labels:     lbInput.Labels,
+					}, nil)
 			},
 			wantID:     "lb1",
 			wantStatus: config.LB_ACTIVE_STATUS,
@@ -184,6 +228,256 @@ func TestEnsureLB(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("repair lb modified out of band", func(t *testing.T) {
+		g := NewWithT(t)
+		manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+		lbInput, err := manager.buildLBInput(gwInfo, map[string]string{"example.com": "cert-id"})
+		g.Expect(err).To(BeNil())
+		current := &serverscom.L7LoadBalancer{
+			ID:         "lb1",
+			Name:       lbInput.Name,
+			Status:     config.LB_ACTIVE_STATUS,
+			LocationID: 1,
+			Domains:    []string{"example.com"},
+			Labels:     lbInput.Labels,
+			Updated:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		manager.written["lb1"] = writtenLB{hash: lbInput.Labels[config.LB_CONFIG_HASH_LABEL], updated: current.Updated.Add(-time.Hour)}
+
+		lbHandler.EXPECT().Collection().Return(collectionHandler)
+		collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).AnyTimes().Return(collectionHandler)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "lb1", Status: config.LB_ACTIVE_STATUS}}, nil)
+		lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(current, nil)
+		lbHandler.EXPECT().UpdateL7LoadBalancer(gomock.Any(), "lb1", translateCreateToUpdateInput(lbInput)).Return(current, nil)
+
+		_, err = manager.EnsureLB(context.Background(), gwInfo, map[string]string{"example.com": "cert-id"})
+		g.Expect(err).To(BeNil())
+		// update time after repair is taken as new baseline
+		g.Expect(manager.written["lb1"].updated.IsZero()).To(BeTrue())
+	})
+
+	t.Run("report only mode doesn't repair lb modified out of band", func(t *testing.T) {
+		g := NewWithT(t)
+		manager := NewManager(client, "", config.DefaultKeys(), 0, 0, true)
+		lbInput, err := manager.buildLBInput(gwInfo, map[string]string{"example.com": "cert-id"})
+		g.Expect(err).To(BeNil())
+		current := &serverscom.L7LoadBalancer{
+			ID:         "lb1",
+			Name:       lbInput.Name,
+			Status:     config.LB_ACTIVE_STATUS,
+			LocationID: 1,
+			Domains:    []string{"example.com", "manual.com"},
+			Labels:     lbInput.Labels,
+			Updated:    time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		manager.written["lb1"] = writtenLB{hash: lbInput.Labels[config.LB_CONFIG_HASH_LABEL], updated: current.Updated.Add(-time.Hour)}
+
+		lbHandler.EXPECT().Collection().Return(collectionHandler)
+		collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).AnyTimes().Return(collectionHandler)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "lb1", Status: config.LB_ACTIVE_STATUS}}, nil)
+		lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(current, nil)
+
+		res, err := manager.EnsureLB(context.Background(), gwInfo, map[string]string{"example.com": "cert-id"})
+		g.Expect(err).To(BeNil())
+		g.Expect(res).To(Equal(current))
+	})
+
+	t.Run("report only mode applies desired config change", func(t *testing.T) {
+		g := NewWithT(t)
+		manager := NewManager(client, "", config.DefaultKeys(), 0, 0, true)
+		lbInput, err := manager.buildLBInput(gwInfo, map[string]string{"example.com": "cert-id"})
+		g.Expect(err).To(BeNil())
+		labels := maps.Clone(lbInput.Labels)
+		labels[config.LB_CONFIG_HASH_LABEL] = "old"
+		current := &serverscom.L7LoadBalancer{
+			ID:         "lb1",
+			Name:       lbInput.Name,
+			Status:     config.LB_ACTIVE_STATUS,
+			LocationID: 1,
+			Labels:     labels,
+		}
+
+		lbHandler.EXPECT().Collection().Return(collectionHandler)
+		collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).AnyTimes().Return(collectionHandler)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "lb1", Status: config.LB_ACTIVE_STATUS}}, nil)
+		lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(current, nil)
+		lbHandler.EXPECT().UpdateL7LoadBalancer(gomock.Any(), "lb1", translateCreateToUpdateInput(lbInput)).Return(current, nil)
+
+		_, err = manager.EnsureLB(context.Background(), gwInfo, map[string]string{"example.com": "cert-id"})
+		g.Expect(err).To(BeNil())
+	})
+}
+
+func TestDetectDrift(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+
+	gwInfo := &types.GatewayInfo{
+		UID: "gw-uid",
+		VHosts: map[string]*types.VHostInfo{
+			"example.com": {
+				Host:  "example.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path: "/",
+					Backends: []types.BackendInfo{{
+						Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+						NodePort: 8080,
+						Weight:   1,
+					}},
+					NodeIps: []string{"1.1.1.1"},
+				}},
+			},
+		},
+	}
+	lbInput, err := NewManager(client, "", config.DefaultKeys(), 0, 0, false).buildLBInput(gwInfo, nil)
+	NewWithT(t).Expect(err).To(BeNil())
+	hash := lbInput.Labels[config.LB_CONFIG_HASH_LABEL]
+	updated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	desiredLB := func() *serverscom.L7LoadBalancer {
+		labels := map[string]string{}
+		for k, v := range lbInput.Labels {
+			labels[k] = v
+		}
+		return &serverscom.L7LoadBalancer{
+			ID:      "lb1",
+			Name:    lbInput.Name,
+			Status:  config.LB_ACTIVE_STATUS,
+			Domains: []string{"example.com"},
+			Labels:  labels,
+			Updated: updated,
+		}
+	}
+	listLBs := func(lbs ...serverscom.LoadBalancer) {
+		lbHandler.EXPECT().Collection().Return(collectionHandler)
+		collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Times(2).Return(collectionHandler)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(lbs, nil)
+	}
+
+	tests := []struct {
+		name       string
+		written    map[string]writtenLB
+		setupMocks func()
+		wantDrift  []string
+	}{
+		{
+			name: "lb not found",
+			setupMocks: func() {
+				listLBs()
+			},
+			wantDrift: []string{"load balancer not found"},
+		},
+		{
+			name: "lb in sync",
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(desiredLB(), nil)
+			},
+		},
+		{
+			name: "lb domains changed out of band",
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lb := desiredLB()
+				lb.Domains = []string{"example.com", "manual.com"}
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(lb, nil)
+			},
+			wantDrift: []string{"domains [example.com manual.com], expected [example.com]"},
+		},
+		{
+			name:    "outdated lb is not drift",
+			written: map[string]writtenLB{"lb1": {hash: "old"}},
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lb := desiredLB()
+				lb.Domains = nil
+				lb.Labels[config.LB_CONFIG_HASH_LABEL] = "old"
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(lb, nil)
+			},
+		},
+		{
+			// e.g. controller restarted after gateway change, before LB update
+			name: "pending config change of lb not written by controller is not drift",
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lb := desiredLB()
+				lb.Domains = nil
+				lb.Labels[config.LB_CONFIG_HASH_LABEL] = "old"
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(lb, nil)
+			},
+		},
+		{
+			name:    "lb zones changed out of band",
+			written: map[string]writtenLB{"lb1": {hash: hash, updated: updated.Add(-time.Hour)}},
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(desiredLB(), nil)
+			},
+			wantDrift: []string{"modified outside of controller at 2025-01-01T00:00:00Z"},
+		},
+		{
+			name:    "outdated lb changed out of band",
+			written: map[string]writtenLB{"lb1": {hash: "old", updated: updated.Add(-time.Hour)}},
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lb := desiredLB()
+				lb.Labels[config.LB_CONFIG_HASH_LABEL] = "old"
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(lb, nil)
+			},
+			wantDrift: []string{"modified outside of controller at 2025-01-01T00:00:00Z"},
+		},
+		{
+			name:    "lb written by controller is seen first time",
+			written: map[string]writtenLB{"lb1": {hash: hash}},
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+				lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(desiredLB(), nil)
+			},
+		},
+		{
+			name: "lb not active",
+			setupMocks: func() {
+				listLBs(serverscom.LoadBalancer{ID: "lb1", Status: "pending"})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+			for id, state := range tt.written {
+				manager.written[id] = state
+			}
+			tt.setupMocks()
+
+			drift, err := manager.DetectDrift(context.Background(), gwInfo, nil)
+			g.Expect(err).To(BeNil())
+			g.Expect(drift).To(Equal(tt.wantDrift))
+		})
+	}
+
+	t.Run("lb update time is taken as baseline", func(t *testing.T) {
+		g := NewWithT(t)
+		manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+		manager.recordWritten("lb1", lbInput)
+
+		for i := 0; i < 2; i++ {
+			listLBs(serverscom.LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS})
+			lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb1").Return(desiredLB(), nil)
+			drift, err := manager.DetectDrift(context.Background(), gwInfo, nil)
+			g.Expect(err).To(BeNil())
+			g.Expect(drift).To(BeEmpty())
+		}
+		g.Expect(manager.written["lb1"]).To(Equal(writtenLB{hash: hash, updated: updated}))
+	})
 }
 
 func TestDeleteLB(t *testing.T) {
@@ -195,9 +489,9 @@ func TestDeleteLB(t *testing.T) {
 
 	client := serverscom.NewClientWithEndpoint("", "")
 	client.LoadBalancers = lbHandler
-	manager := NewManager(client)
+	manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
 
-	label := "gw=uid"
+	label := config.GW_LABEL_ID + "=uid"
 
 	tests := []struct {
 		name       string
@@ -271,7 +565,7 @@ func TestDeleteLB(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			g := NewWithT(t)
 			tt.setupMocks()
-			err := manager.DeleteLB(context.Background(), label)
+			err := manager.DeleteLB(context.Background(), "uid")
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
 			} else {
@@ -281,76 +575,571 @@ func TestDeleteLB(t *testing.T) {
 	}
 }
 
-func TestTranslateGatewayToLBInput(t *testing.T) {
+func TestListManagedLBs(t *testing.T) {
 	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
 
-	certMap := map[string]string{
-		"example.com": "cert-id",
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+
+	lbHandler.EXPECT().Collection().Return(collectionHandler).Times(4)
+	collectionHandler.EXPECT().SetParam("type", "l7").Return(collectionHandler).Times(4)
+	collectionHandler.EXPECT().SetParam("label_selector", config.GW_LABEL_ID).Return(collectionHandler).Times(2)
+	collectionHandler.EXPECT().SetParam("label_selector", config.RETAINED_LABEL_ID).Return(collectionHandler).Times(2)
+	gomock.InOrder(
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "lb1"}}, nil),
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "lb2"}}, nil),
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, &serverscom.NotFoundError{Message: "Not found"}),
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, &serverscom.NotFoundError{Message: "Not found"}),
+	)
+
+	lbs, err := manager.ListManagedLBs(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(lbs).To(HaveLen(2))
+
+	lbs, err = manager.ListManagedLBs(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(lbs).To(BeEmpty())
+}
+
+func TestRetainLB(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam("type", "l7").Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam("label_selector", config.GW_LABEL_ID+"=gw-uid").Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "cluster-a", config.DefaultKeys(), 0, 0, false)
+
+	t.Run("gateway label replaced by retained label", func(t *testing.T) {
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{
+			ID: "lb1",
+			Labels: map[string]string{
+				config.GW_LABEL_ID:                               "gw-uid",
+				config.CLUSTER_LABEL_ID:                          "cluster-a",
+				config.LB_CONFIG_HASH_LABEL:                      "hash",
+				CertificateLabel(config.DefaultKeys(), "cert-1"): "true",
+				"user-label":                                     "value",
+			},
+		}}, nil)
+		lbHandler.EXPECT().
+			UpdateL7LoadBalancer(gomock.Any(), "lb1", serverscom.L7LoadBalancerUpdateInput{
+				Labels: map[string]string{
+					config.RETAINED_LABEL_ID:                         "gw-uid",
+					config.CLUSTER_LABEL_ID:                          "cluster-a",
+					CertificateLabel(config.DefaultKeys(), "cert-1"): "true",
+					"user-label":                                     "value",
+				},
+			}).
+			Return(&serverscom.L7LoadBalancer{ID: "lb1"}, nil)
+
+		g.Expect(manager.RetainLB(context.Background(), "gw-uid")).To(Succeed())
+	})
+
+	t.Run("nothing to retain", func(t *testing.T) {
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+
+		g.Expect(manager.RetainLB(context.Background(), "gw-uid")).To(Succeed())
+	})
+
+	t.Run("lb of another cluster is not touched", func(t *testing.T) {
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{
+			ID:     "lb2",
+			Labels: map[string]string{config.GW_LABEL_ID: "gw-uid", config.CLUSTER_LABEL_ID: "cluster-b"},
+		}}, nil)
+
+		g.Expect(manager.RetainLB(context.Background(), "gw-uid")).To(Succeed())
+	})
+}
+
+func TestDeleteLBByID(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+
+	lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb1").Return(nil)
+	lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb2").Return(&serverscom.NotFoundError{Message: "Not found"})
+	lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb3").Return(errors.New("api error"))
+
+	g.Expect(manager.DeleteLBByID(context.Background(), "lb1")).To(BeNil())
+	g.Expect(manager.DeleteLBByID(context.Background(), "lb2")).To(BeNil())
+	g.Expect(manager.DeleteLBByID(context.Background(), "lb3")).To(HaveOccurred())
+}
+
+func TestClusterOwnership(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "cluster-a", config.DefaultKeys(), 0, 0, false)
+
+	gwInfo := &types.GatewayInfo{
+		UID: "gw-uid",
+		VHosts: map[string]*types.VHostInfo{
+			"example.com": {
+				Host:  "example.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path:     "/",
+					Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+					NodeIps:  []string{"1.1.1.1"},
+				}},
+			},
+		},
+	}
+	own := serverscom.LoadBalancer{ID: "own", Labels: map[string]string{config.CLUSTER_LABEL_ID: "cluster-a"}}
+	legacy := serverscom.LoadBalancer{ID: "legacy", Labels: map[string]string{}}
+	foreign := serverscom.LoadBalancer{ID: "foreign", Name: "gw-foreign", Labels: map[string]string{config.CLUSTER_LABEL_ID: "cluster-b"}}
+
+	t.Run("create stamps cluster label", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		lbHandler.EXPECT().
+			CreateL7LoadBalancer(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, in serverscom.L7LoadBalancerCreateInput) (*serverscom.L7LoadBalancer, error) {
+				g.Expect(in.Labels).To(HaveKeyWithValue(config.CLUSTER_LABEL_ID, "cluster-a"))
+				return &serverscom.L7LoadBalancer{ID: "lb"}, nil
+			})
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("lb of another cluster is conflict", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{foreign}, nil)
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		var conflictErr *utils.ClusterConflictError
+		g.Expect(errors.As(err, &conflictErr)).To(BeTrue())
+		g.Expect(conflictErr.ClusterID).To(Equal("cluster-b"))
+	})
+
+	t.Run("delete skips lb of another cluster", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{foreign}, nil)
+		g.Expect(manager.DeleteLB(context.Background(), "uid")).To(BeNil())
+	})
+
+	t.Run("list returns own and legacy lbs", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{own, legacy, foreign}, nil)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		lbs, err := manager.ListManagedLBs(context.Background())
+		g.Expect(err).To(BeNil())
+		g.Expect(lbs).To(Equal([]serverscom.LoadBalancer{own, legacy}))
+	})
+}
+
+func TestLabelKeysMigration(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam("type", "l7").Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	keys := config.Keys{GatewayLabel: "staging/gw-id", OldGatewayLabel: config.GW_LABEL_ID}
+	manager := NewManager(client, "", keys, 0, 0, false)
+
+	gwInfo := &types.GatewayInfo{
+		UID: "gw-uid",
+		VHosts: map[string]*types.VHostInfo{
+			"example.com": {
+				Host:  "example.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path:     "/",
+					Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+					NodeIps:  []string{"1.1.1.1"},
+				}},
+			},
+		},
+	}
+	lbInput, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+	NewWithT(t).Expect(err).To(BeNil())
+	oldLabels := map[string]string{
+		config.GW_LABEL_ID:          "gw-uid",
+		config.LB_CONFIG_HASH_LABEL: lbInput.Labels[config.LB_CONFIG_HASH_LABEL],
+	}
+	expectLookup := func(newKeyResult, oldKeyResult []serverscom.LoadBalancer) {
+		collectionHandler.EXPECT().SetParam("label_selector", "staging/gw-id=gw-uid").Return(collectionHandler)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(newKeyResult, nil)
+		if newKeyResult == nil {
+			collectionHandler.EXPECT().SetParam("label_selector", config.GW_LABEL_ID+"=gw-uid").Return(collectionHandler)
+			collectionHandler.EXPECT().Collect(gomock.Any()).Return(oldKeyResult, nil)
+		}
+	}
+
+	t.Run("lb with old key is relabelled", func(t *testing.T) {
+		g := NewWithT(t)
+		expectLookup(nil, []serverscom.LoadBalancer{{ID: "lb1", Status: config.LB_ACTIVE_STATUS, Labels: oldLabels}})
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "lb1").
+			Return(&serverscom.L7LoadBalancer{ID: "lb1", Name: "gw-gw-uid", LocationID: 1, Domains: []string{"example.com"}, Labels: oldLabels}, nil)
+		lbHandler.EXPECT().
+			UpdateL7LoadBalancer(gomock.Any(), "lb1", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, in serverscom.L7LoadBalancerUpdateInput) (*serverscom.L7LoadBalancer, error) {
+				g.Expect(in.Labels).To(HaveKeyWithValue("staging/gw-id", "gw-uid"))
+				g.Expect(in.Labels).To(HaveKey("staging/gw-id-config-hash"))
+				g.Expect(in.Labels).ToNot(HaveKey(config.GW_LABEL_ID))
+				g.Expect(in.Labels).ToNot(HaveKey(config.LB_CONFIG_HASH_LABEL))
+				return &serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS}, nil
+			})
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("lb with old key is not reported as drift", func(t *testing.T) {
+		g := NewWithT(t)
+		expectLookup(nil, []serverscom.LoadBalancer{{ID: "lb1", Status: config.LB_ACTIVE_STATUS, Labels: oldLabels}})
+		drift, err := manager.DetectDrift(context.Background(), gwInfo, nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(drift).To(BeEmpty())
+	})
+
+	t.Run("lb with current key found first", func(t *testing.T) {
+		g := NewWithT(t)
+		expectLookup([]serverscom.LoadBalancer{{ID: "lb1"}}, nil)
+		lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb1").Return(nil)
+		g.Expect(manager.DeleteLB(context.Background(), "gw-uid")).To(BeNil())
+	})
+
+	t.Run("lb of another cluster with old key is conflict", func(t *testing.T) {
+		g := NewWithT(t)
+		manager := NewManager(client, "a", keys, 0, 0, false)
+		foreign := map[string]string{config.GW_LABEL_ID: "gw-uid", config.CLUSTER_LABEL_ID: "b"}
+		expectLookup(nil, []serverscom.LoadBalancer{{ID: "lb1", Status: config.LB_ACTIVE_STATUS, Labels: foreign}})
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		var conflictErr *utils.ClusterConflictError
+		g.Expect(errors.As(err, &conflictErr)).To(BeTrue())
+		g.Expect(conflictErr.ClusterID).To(Equal("b"))
+	})
+
+	t.Run("lbs of instance and old keys are listed", func(t *testing.T) {
+		g := NewWithT(t)
+		oldLB := serverscom.LoadBalancer{ID: "lb-old", Labels: map[string]string{config.GW_LABEL_ID: "gw-uid"}}
+		gomock.InOrder(
+			collectionHandler.EXPECT().SetParam("label_selector", "staging/gw-id").Return(collectionHandler),
+			collectionHandler.EXPECT().SetParam("label_selector", "staging/gw-id-retained-gateway-id").Return(collectionHandler),
+			collectionHandler.EXPECT().SetParam("label_selector", config.GW_LABEL_ID).Return(collectionHandler),
+			collectionHandler.EXPECT().SetParam("label_selector", config.RETAINED_LABEL_ID).Return(collectionHandler),
+		)
+		gomock.InOrder(
+			collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "lb-new"}}, nil),
+			collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil),
+			collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, {ID: "lb-new"}}, nil),
+			collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil),
+		)
+		lbs, err := manager.ListManagedLBs(context.Background())
+		g.Expect(err).To(BeNil())
+		g.Expect(lbs).To(Equal([]serverscom.LoadBalancer{{ID: "lb-new"}, oldLB}))
+	})
+
+	t.Run("certificates of lbs with old key are in use", func(t *testing.T) {
+		g := NewWithT(t)
+		lbs := []serverscom.LoadBalancer{
+			{Labels: map[string]string{CertificateLabel(keys, "cert-a"): "true"}},
+			{Labels: map[string]string{CertificateLabel(config.DefaultKeys(), "cert-b"): "true"}},
+		}
+		g.Expect(CertificatesInUse(lbs, keys)).To(Equal(map[string]bool{"cert-a": true, "cert-b": true}))
+		g.Expect(CertificatesInUse(lbs, config.Keys{GatewayLabel: "staging/gw-id"})).To(Equal(map[string]bool{"cert-a": true}))
+	})
+}
+
+func TestAdoptLB(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+
+	gwInfo := &types.GatewayInfo{
+		UID:       "gw-uid",
+		AdoptLBID: "existing",
+		VHosts: map[string]*types.VHostInfo{
+			"example.com": {
+				Host:  "example.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path:     "/",
+					Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+					NodeIps:  []string{"1.1.1.1"},
+				}},
+			},
+		},
 	}
 
 	tests := []struct {
-		name      string
-		gwInfo    *types.GatewayInfo
-		hostCerts map[string]string
-		wantErr   bool
-		verify    func(lbInput *serverscom.L7LoadBalancerCreateInput)
+		name       string
+		setupMocks func(g *WithT)
+		wantErr    bool
 	}{
 		{
-			name: "single vhost, single path, SSL on",
-			gwInfo: &types.GatewayInfo{
-				UID: "gw1",
-				VHosts: map[string]*types.VHostInfo{
-					"example.com": {
-						Host:  "example.com",
-						SSL:   true,
-						Ports: []int32{443},
-						Paths: []types.PathInfo{
-							{
-								Path: "/",
-								Service: &corev1.Service{
-									ObjectMeta: metav1.ObjectMeta{Name: "svc1"},
-								},
-								NodePort: 8080,
-								NodeIps:  []string{"1.1.1.1"},
-							},
-						},
-					},
-				},
+			name: "adopt unlabelled l7 lb",
+			setupMocks: func(g *WithT) {
+				collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+				lbHandler.EXPECT().
+					GetL7LoadBalancer(gomock.Any(), "existing").
+					Return(&serverscom.L7LoadBalancer{ID: "existing", Type: "l7", Status: "active", LocationID: 1, ExternalAddresses: []string{"1.2.3.4"}}, nil)
+				lbHandler.EXPECT().
+					UpdateL7LoadBalancer(gomock.Any(), "existing", gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ string, in serverscom.L7LoadBalancerUpdateInput) (*serverscom.L7LoadBalancer, error) {
+						g.Expect(in.Labels).To(HaveKeyWithValue(config.GW_LABEL_ID, "gw-uid"))
+						return &serverscom.L7LoadBalancer{ID: "existing", Status: "active", ExternalAddresses: []string{"1.2.3.4"}}, nil
+					})
 			},
-			hostCerts: certMap,
-			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
-				g.Expect(len(lbInput.VHostZones)).To(Equal(1))
-				vh := lbInput.VHostZones[0]
-				g.Expect(vh.SSL).To(BeTrue())
-				g.Expect(vh.SSLCertID).To(Equal("cert-id"))
-				g.Expect(len(lbInput.UpstreamZones)).To(Equal(1))
+		},
+		{
+			name: "lb owned by another gateway",
+			setupMocks: func(g *WithT) {
+				collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+				lbHandler.EXPECT().
+					GetL7LoadBalancer(gomock.Any(), "existing").
+					Return(&serverscom.L7LoadBalancer{ID: "existing", Type: "l7", Labels: map[string]string{config.GW_LABEL_ID: "other"}}, nil)
 			},
+			wantErr: true,
 		},
 		{
-			name: "single vhost, multiple paths",
-			gwInfo: &types.GatewayInfo{
-				UID: "gw2",
-				VHosts: map[string]*types.VHostInfo{
-					"example.org": {
+			name: "lb is not l7",
+			setupMocks: func(g *WithT) {
+				collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+				lbHandler.EXPECT().
+					GetL7LoadBalancer(gomock.Any(), "existing").
+					Return(&serverscom.L7LoadBalancer{ID: "existing", Type: "l4"}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "lb not found",
+			setupMocks: func(g *WithT) {
+				collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+				lbHandler.EXPECT().
+					GetL7LoadBalancer(gomock.Any(), "existing").
+					Return(nil, &serverscom.NotFoundError{Message: "Not found"})
+			},
+			wantErr: true,
+		},
+		{
+			name: "gateway already has another lb",
+			setupMocks: func(g *WithT) {
+				collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{{ID: "managed"}}, nil)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			tt.setupMocks(g)
+			lb, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+			if tt.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				return
+			}
+			g.Expect(err).To(BeNil())
+			g.Expect(lb.ExternalAddresses).To(Equal([]string{"1.2.3.4"}))
+		})
+	}
+}
+
+func TestTranslateGatewayToLBInputIsDeterministic(t *testing.T) {
+	g := NewWithT(t)
+
+	gwInfo := &types.GatewayInfo{UID: "gw", VHosts: map[string]*types.VHostInfo{}}
+	for _, host := range []string{"a.com", "b.com", "c.com", "d.com"} {
+		gwInfo.VHosts[host] = &types.VHostInfo{
+			Host:  host,
+			Ports: []int32{80},
+			Paths: []types.PathInfo{{
+				Path: "/",
+				Backends: []types.BackendInfo{{
+					Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc-" + host}},
+					NodePort: 8080,
+					Weight:   1,
+				}},
+				NodeIps: []string{"3.3.3.3", "1.1.1.1", "2.2.2.2"},
+			}},
+		}
+	}
+
+	first, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+	g.Expect(err).To(BeNil())
+	g.Expect(first.VHostZones[0].Domains).To(Equal([]string{"a.com"}))
+	g.Expect(first.UpstreamZones[0].Upstreams[0].IP).To(Equal("1.1.1.1"))
+	for i := 0; i < 10; i++ {
+		next, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+		g.Expect(err).To(BeNil())
+		g.Expect(next).To(Equal(first))
+	}
+}
+
+func TestCertificatesTracking(t *testing.T) {
+	g := NewWithT(t)
+
+	gwInfo := &types.GatewayInfo{UID: "gw", VHosts: map[string]*types.VHostInfo{}}
+	for _, host := range []string{"a.com", "b.com"} {
+		gwInfo.VHosts[host] = &types.VHostInfo{
+			Host:  host,
+			SSL:   true,
+			Ports: []int32{443},
+			Paths: []types.PathInfo{{
+				Path:     "/",
+				Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+				NodeIps:  []string{"1.1.1.1"},
+			}},
+		}
+	}
+
+	lbInput, err := translateGatewayToLBInput(gwInfo, map[string]string{"a.com": "cert-a"}, config.DefaultKeys())
+	g.Expect(err).To(BeNil())
+	g.Expect(lbInput.Labels).To(HaveKeyWithValue(CertificateLabel(config.DefaultKeys(), "cert-a"), "true"))
+
+	lbs := []serverscom.LoadBalancer{
+		{Labels: lbInput.Labels},
+		{Labels: map[string]string{CertificateLabel(config.DefaultKeys(), "cert-b"): "true", config.LB_CERT_LABEL_PREFIX: "true"}},
+	}
+	g.Expect(CertificatesInUse(lbs, config.DefaultKeys())).To(Equal(map[string]bool{"cert-a": true, "cert-b": true}))
+}
+
+func TestLBNeedsUpdate(t *testing.T) {
+	g := NewWithT(t)
+
+	in := serverscom.L7LoadBalancerUpdateInput{
+		Name:       "gw-a",
+		Geoip:      utils.BoolPtr(false),
+		VHostZones: []serverscom.L7VHostZoneInput{{Domains: []string{"b.com"}}, {Domains: []string{"a.com"}}},
+		Labels:     map[string]string{config.LB_CONFIG_HASH_LABEL: "hash"},
+	}
+	actual := func() *serverscom.L7LoadBalancer {
+		return &serverscom.L7LoadBalancer{
+			Name:    "gw-a",
+			Domains: []string{"a.com", "b.com"},
+			Labels:  map[string]string{config.LB_CONFIG_HASH_LABEL: "hash", "extra": "label"},
+		}
+	}
+	g.Expect(lbNeedsUpdate(actual(), in)).To(BeFalse())
+
+	lb := actual()
+	lb.Labels[config.LB_CONFIG_HASH_LABEL] = "old"
+	g.Expect(lbNeedsUpdate(lb, in)).To(BeTrue())
+
+	lb = actual()
+	lb.Domains = []string{"a.com"}
+	g.Expect(lbNeedsUpdate(lb, in)).To(BeTrue())
+
+	lb = actual()
+	lb.Geoip = true
+	g.Expect(lbNeedsUpdate(lb, in)).To(BeTrue())
+
+	lb = actual()
+	lb.Name = "renamed"
+	g.Expect(lbNeedsUpdate(lb, in)).To(BeTrue())
+}
+
+func TestTranslateGatewayToLBInput(t *testing.T) {
+	g := NewWithT(t)
+
+	certMap := map[string]string{
+		"example.com": "cert-id",
+	}
+
+	tests := []struct {
+		name      string
+		gwInfo    *types.GatewayInfo
+		hostCerts map[string]string
+		wantErr   bool
+		verify    func(lbInput *serverscom.L7LoadBalancerCreateInput)
+	}{
+		{
+			name: "single vhost, single path, SSL on",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw1",
+				VHosts: map[string]*types.VHostInfo{
+					"example.com": {
+						Host:  "example.com",
+						SSL:   true,
+						Ports: []int32{443},
+						Paths: []types.PathInfo{
+							{
+								Path: "/",
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc1"}},
+									NodePort: 8080,
+									Weight:   1,
+								}},
+								NodeIps: []string{"1.1.1.1"},
+							},
+						},
+					},
+				},
+			},
+			hostCerts: certMap,
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(len(lbInput.VHostZones)).To(Equal(1))
+				vh := lbInput.VHostZones[0]
+				g.Expect(vh.SSL).To(BeTrue())
+				g.Expect(vh.SSLCertID).To(Equal("cert-id"))
+				g.Expect(len(lbInput.UpstreamZones)).To(Equal(1))
+			},
+		},
+		{
+			name: "single vhost, multiple paths",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw2",
+				VHosts: map[string]*types.VHostInfo{
+					"example.org": {
 						Host:  "example.org",
 						SSL:   false,
 						Ports: []int32{80},
 						Paths: []types.PathInfo{
 							{
 								Path: "/api",
-								Service: &corev1.Service{
-									ObjectMeta: metav1.ObjectMeta{Name: "svc2"},
-								},
-								NodePort: 8081,
-								NodeIps:  []string{"2.2.2.2"},
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc2"}},
+									NodePort: 8081,
+									Weight:   1,
+								}},
+								NodeIps: []string{"2.2.2.2"},
 							},
 							{
 								Path: "/web",
-								Service: &corev1.Service{
-									ObjectMeta: metav1.ObjectMeta{Name: "svc3"},
-								},
-								NodePort: 8082,
-								NodeIps:  []string{"3.3.3.3"},
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc3"}},
+									NodePort: 8082,
+									Weight:   1,
+								}},
+								NodeIps: []string{"3.3.3.3"},
 							},
 						},
 					},
@@ -375,11 +1164,12 @@ func TestTranslateGatewayToLBInput(t *testing.T) {
 						Paths: []types.PathInfo{
 							{
 								Path: "/",
-								Service: &corev1.Service{
-									ObjectMeta: metav1.ObjectMeta{Name: "svcA"},
-								},
-								NodePort: 8080,
-								NodeIps:  []string{"1.1.1.1"},
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svcA"}},
+									NodePort: 8080,
+									Weight:   1,
+								}},
+								NodeIps: []string{"1.1.1.1"},
 							},
 						},
 					},
@@ -390,11 +1180,12 @@ func TestTranslateGatewayToLBInput(t *testing.T) {
 						Paths: []types.PathInfo{
 							{
 								Path: "/",
-								Service: &corev1.Service{
-									ObjectMeta: metav1.ObjectMeta{Name: "svcB"},
-								},
-								NodePort: 8081,
-								NodeIps:  []string{"2.2.2.2"},
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svcB"}},
+									NodePort: 8081,
+									Weight:   1,
+								}},
+								NodeIps: []string{"2.2.2.2"},
 							},
 						},
 					},
@@ -429,6 +1220,114 @@ func TestTranslateGatewayToLBInput(t *testing.T) {
 			hostCerts: nil,
 			wantErr:   true,
 		},
+		{
+			name: "exact and regular expression paths",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw6",
+				VHosts: map[string]*types.VHostInfo{
+					"paths.com": {
+						Host:  "paths.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path:     "/api",
+								PathType: gatewayv1.PathMatchPathPrefix,
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+									NodePort: 8080,
+									Weight:   1,
+								}},
+								NodeIps: []string{"1.1.1.1"},
+							},
+							{
+								Path:     "/healthz",
+								PathType: gatewayv1.PathMatchExact,
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+									NodePort: 8080,
+									Weight:   1,
+								}},
+								NodeIps: []string{"1.1.1.1"},
+							},
+							{
+								Path:     "^/v[0-9]+/",
+								PathType: gatewayv1.PathMatchRegularExpression,
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+									NodePort: 8080,
+									Weight:   1,
+								}},
+								NodeIps: []string{"1.1.1.1"},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				locations := []string{}
+				for _, l := range lbInput.VHostZones[0].LocationZones {
+					locations = append(locations, l.Location)
+				}
+				g.Expect(locations).To(Equal([]string{"/api", "= /healthz", `~ "^/v[0-9]+/"`}))
+				g.Expect(len(lbInput.UpstreamZones)).To(Equal(1))
+			},
+		},
+		{
+			name: "weighted backends",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw7",
+				VHosts: map[string]*types.VHostInfo{
+					"canary.com": {
+						Host:  "canary.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path: "/",
+								Backends: []types.BackendInfo{
+									{
+										Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "stable"}},
+										NodePort: 8080,
+										Weight:   90,
+									},
+									{
+										Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "canary"}},
+										NodePort: 8081,
+										Weight:   10,
+									},
+									{
+										Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "old"}},
+										NodePort: 8082,
+										Weight:   0,
+									},
+								},
+								NodeIps: []string{"1.1.1.1", "2.2.2.2"},
+							},
+							{
+								Path: "/zero",
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "old"}},
+									NodePort: 8082,
+									Weight:   0,
+								}},
+								NodeIps: []string{"1.1.1.1", "2.2.2.2"},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(lbInput.VHostZones[0].LocationZones).To(HaveLen(1))
+				g.Expect(lbInput.UpstreamZones).To(HaveLen(1))
+				ups := lbInput.UpstreamZones[0].Upstreams
+				g.Expect(ups).To(ConsistOf(
+					serverscom.L7UpstreamInput{IP: "1.1.1.1", Port: 8080, Weight: 90},
+					serverscom.L7UpstreamInput{IP: "2.2.2.2", Port: 8080, Weight: 90},
+					serverscom.L7UpstreamInput{IP: "1.1.1.1", Port: 8081, Weight: 10},
+					serverscom.L7UpstreamInput{IP: "2.2.2.2", Port: 8081, Weight: 10},
+				))
+				g.Expect(lbInput.VHostZones[0].LocationZones[0].UpstreamID).To(Equal(lbInput.UpstreamZones[0].ID))
+			},
+		},
 		{
 			name: "SSL enabled but no cert in hostCerts",
 			gwInfo: &types.GatewayInfo{
@@ -441,11 +1340,12 @@ func TestTranslateGatewayToLBInput(t *testing.T) {
 						Paths: []types.PathInfo{
 							{
 								Path: "/",
-								Service: &corev1.Service{
-									ObjectMeta: metav1.ObjectMeta{Name: "svc"},
-								},
-								NodePort: 8080,
-								NodeIps:  []string{"1.1.1.1"},
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+									NodePort: 8080,
+									Weight:   1,
+								}},
+								NodeIps: []string{"1.1.1.1"},
 							},
 						},
 					},
@@ -457,11 +1357,234 @@ func TestTranslateGatewayToLBInput(t *testing.T) {
 				g.Expect(lbInput.VHostZones[0].SSLCertID).To(Equal(""))
 			},
 		},
+		{
+			name: "draining nodes get minimal weight",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw-drain",
+				VHosts: map[string]*types.VHostInfo{
+					"example.com": {
+						Host:  "example.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path: "/",
+								Backends: []types.BackendInfo{{
+									Service:  &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+									NodePort: 8080,
+									Weight:   10,
+								}},
+								NodeIps:         []string{"1.1.1.1"},
+								DrainingNodeIps: []string{"2.2.2.2"},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(lbInput.UpstreamZones[0].Upstreams).To(Equal([]serverscom.L7UpstreamInput{
+					{IP: "1.1.1.1", Port: 8080, Weight: 1},
+					{IP: "2.2.2.2", Port: 8080, Weight: 1, MaxConns: 1},
+				}))
+			},
+		},
+		{
+			name: "local endpoints limit nodes",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw-local",
+				VHosts: map[string]*types.VHostInfo{
+					"example.com": {
+						Host:  "example.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path: "/",
+								Backends: []types.BackendInfo{{
+									Service:        &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}},
+									NodePort:       8080,
+									Weight:         1,
+									LocalEndpoints: map[string]int32{"1.1.1.1": 2, "3.3.3.3": 1},
+								}},
+								NodeIps:         []string{"1.1.1.1", "2.2.2.2"},
+								DrainingNodeIps: []string{"3.3.3.3"},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(lbInput.UpstreamZones[0].Upstreams).To(Equal([]serverscom.L7UpstreamInput{
+					{IP: "1.1.1.1", Port: 8080, Weight: 2},
+					{IP: "3.3.3.3", Port: 8080, Weight: 1, MaxConns: 1},
+				}))
+			},
+		},
+		{
+			name: "pod upstreams",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw-pods",
+				VHosts: map[string]*types.VHostInfo{
+					"example.com": {
+						Host:  "example.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path: "/",
+								Backends: []types.BackendInfo{{
+									Service:      &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default"}},
+									ServicePort:  80,
+									Weight:       1,
+									Direct:       true,
+									PodEndpoints: []types.PodEndpoint{{IP: "10.244.0.1", Port: 8080}, {IP: "10.244.0.2", Port: 8081}},
+								}},
+								NodeIps: []string{"1.1.1.1"},
+							},
+							{
+								Path: "/empty",
+								Backends: []types.BackendInfo{{
+									Service:     &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "empty"}},
+									ServicePort: 80,
+									Weight:      1,
+									Direct:      true,
+								}},
+								NodeIps: []string{"1.1.1.1"},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(lbInput.UpstreamZones).To(HaveLen(1))
+				g.Expect(lbInput.UpstreamZones[0].ID).To(Equal("upstream-zone-default-svc-pod-80"))
+				g.Expect(lbInput.UpstreamZones[0].Upstreams).To(Equal([]serverscom.L7UpstreamInput{
+					{IP: "10.244.0.1", Port: 8080, Weight: 1},
+					{IP: "10.244.0.2", Port: 8081, Weight: 1},
+				}))
+				// path without ready pods is skipped
+				g.Expect(lbInput.VHostZones[0].LocationZones).To(HaveLen(1))
+			},
+		},
+		{
+			name: "weights split by backend weight regardless of endpoints count",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw-split",
+				VHosts: map[string]*types.VHostInfo{
+					"example.com": {
+						Host:  "example.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path: "/local",
+								Backends: []types.BackendInfo{
+									{
+										Service:        &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "stable", Namespace: "default"}},
+										NodePort:       8080,
+										Weight:         90,
+										LocalEndpoints: map[string]int32{"1.1.1.1": 1},
+									},
+									{
+										Service:        &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default"}},
+										NodePort:       8081,
+										Weight:         10,
+										LocalEndpoints: map[string]int32{"1.1.1.1": 10, "2.2.2.2": 10},
+									},
+								},
+								NodeIps: []string{"1.1.1.1", "2.2.2.2"},
+							},
+							{
+								Path: "/pods",
+								Backends: []types.BackendInfo{
+									{
+										Service:      &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "stable", Namespace: "default"}},
+										ServicePort:  80,
+										Weight:       90,
+										Direct:       true,
+										PodEndpoints: []types.PodEndpoint{{IP: "10.244.0.1", Port: 8080}},
+									},
+									{
+										Service:     &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "canary", Namespace: "default"}},
+										ServicePort: 80,
+										Weight:      10,
+										Direct:      true,
+										PodEndpoints: []types.PodEndpoint{
+											{IP: "10.244.1.1", Port: 9090},
+											{IP: "10.244.1.2", Port: 9090},
+											{IP: "10.244.1.3", Port: 9090},
+											{IP: "10.244.1.4", Port: 9090},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(lbInput.UpstreamZones).To(HaveLen(2))
+				for _, z := range lbInput.UpstreamZones {
+					var stable, canary int32
+					for _, u := range z.Upstreams {
+						if u.Port == 8080 {
+							stable += u.Weight
+						} else {
+							canary += u.Weight
+						}
+					}
+					g.Expect(stable).To(Equal(9 * canary))
+				}
+				g.Expect(lbInput.UpstreamZones).To(ContainElement(HaveField("Upstreams", ConsistOf(
+					serverscom.L7UpstreamInput{IP: "1.1.1.1", Port: 8080, Weight: 1800},
+					serverscom.L7UpstreamInput{IP: "1.1.1.1", Port: 8081, Weight: 100},
+					serverscom.L7UpstreamInput{IP: "2.2.2.2", Port: 8081, Weight: 100},
+				))))
+			},
+		},
+		{
+			name: "pod upstreams of same service name in different namespaces",
+			gwInfo: &types.GatewayInfo{
+				UID: "gw-pods-ns",
+				VHosts: map[string]*types.VHostInfo{
+					"example.com": {
+						Host:  "example.com",
+						Ports: []int32{80},
+						Paths: []types.PathInfo{
+							{
+								Path: "/a",
+								Backends: []types.BackendInfo{{
+									Service:      &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-a"}},
+									ServicePort:  80,
+									Weight:       1,
+									Direct:       true,
+									PodEndpoints: []types.PodEndpoint{{IP: "10.244.0.1", Port: 8080}},
+								}},
+							},
+							{
+								Path: "/b",
+								Backends: []types.BackendInfo{{
+									Service:      &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "team-b"}},
+									ServicePort:  80,
+									Weight:       1,
+									Direct:       true,
+									PodEndpoints: []types.PodEndpoint{{IP: "10.244.1.1", Port: 8080}},
+								}},
+							},
+						},
+					},
+				},
+			},
+			verify: func(lbInput *serverscom.L7LoadBalancerCreateInput) {
+				g.Expect(lbInput.UpstreamZones).To(HaveLen(2))
+				ids := []string{lbInput.UpstreamZones[0].ID, lbInput.UpstreamZones[1].ID}
+				g.Expect(ids).To(ConsistOf("upstream-zone-team-a-web-pod-80", "upstream-zone-team-b-web-pod-80"))
+				for _, z := range lbInput.UpstreamZones {
+					g.Expect(z.Upstreams).To(HaveLen(1))
+				}
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			lbInput, err := translateGatewayToLBInput(tt.gwInfo, tt.hostCerts)
+			lbInput, err := translateGatewayToLBInput(tt.gwInfo, tt.hostCerts, config.DefaultKeys())
 			if tt.wantErr {
 				g.Expect(err).To(HaveOccurred())
 				return
@@ -470,7 +1593,440 @@ func TestTranslateGatewayToLBInput(t *testing.T) {
 			if tt.verify != nil {
 				tt.verify(lbInput)
 			}
-			g.Expect(lbInput.Labels[config.GW_LABEL_ID]).To(Equal(tt.gwInfo.UID))
 		})
 	}
 }
+
+func TestTranslateGatewayToLBInputParams(t *testing.T) {
+	g := NewWithT(t)
+
+	gwInfo := &types.GatewayInfo{
+		UID: "gw",
+		VHosts: map[string]*types.VHostInfo{
+			"a.com": {
+				Host:  "a.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path:     "/",
+					Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+					NodeIps:  []string{"1.1.1.1"},
+				}},
+			},
+		},
+	}
+
+	defaults, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+	g.Expect(err).To(BeNil())
+	g.Expect(defaults.LocationID).To(Equal(int64(1)))
+	g.Expect(defaults.ClusterID).To(BeNil())
+	g.Expect(defaults.StoreLogs).To(BeNil())
+
+	clusterID := "cluster-1"
+	regionID := 2
+	gwInfo.Params = types.LBParams{
+		LocationID:        3,
+		ClusterID:         &clusterID,
+		StoreLogs:         utils.BoolPtr(true),
+		StoreLogsRegionID: &regionID,
+		Geoip:             utils.BoolPtr(true),
+	}
+	lbInput, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+	g.Expect(err).To(BeNil())
+	g.Expect(lbInput.LocationID).To(Equal(int64(3)))
+	g.Expect(lbInput.ClusterID).To(Equal(&clusterID))
+	g.Expect(lbInput.StoreLogs).To(Equal(utils.BoolPtr(true)))
+	g.Expect(lbInput.StoreLogsRegionID).To(Equal(&regionID))
+	g.Expect(lbInput.Geoip).To(Equal(utils.BoolPtr(true)))
+	g.Expect(lbInput.Labels[config.LB_CONFIG_HASH_LABEL]).NotTo(Equal(defaults.Labels[config.LB_CONFIG_HASH_LABEL]))
+
+	gwInfo.Labels = map[string]string{"team": "payments"}
+	labelled, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+	g.Expect(err).To(BeNil())
+	g.Expect(labelled.Labels).To(HaveKeyWithValue("team", "payments"))
+	g.Expect(labelled.Labels).To(HaveKey(config.LB_CONFIG_HASH_LABEL))
+	g.Expect(gwInfo.Labels).To(HaveLen(1))
+}
+
+func TestLBClusterPlacement(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	clusterHandler := mocks.NewMockLoadBalancerClustersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	client.LoadBalancerClusters = clusterHandler
+	manager := NewManager(client, "", config.DefaultKeys(), 0, 0, false)
+
+	dedicated := "lb-cluster"
+	gwInfo := &types.GatewayInfo{
+		UID: "gw-uid",
+		VHosts: map[string]*types.VHostInfo{
+			"example.com": {
+				Host:  "example.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path:     "/",
+					Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+					NodeIps:  []string{"1.1.1.1"},
+				}},
+			},
+		},
+		Params: types.LBParams{LocationID: 1, ClusterID: &dedicated},
+	}
+
+	t.Run("create on validated cluster", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		clusterHandler.EXPECT().
+			GetLoadBalancerCluster(gomock.Any(), dedicated).
+			Return(&serverscom.LoadBalancerCluster{ID: dedicated, LocationID: 1}, nil)
+		lbHandler.EXPECT().
+			CreateL7LoadBalancer(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, in serverscom.L7LoadBalancerCreateInput) (*serverscom.L7LoadBalancer, error) {
+				g.Expect(in.ClusterID).To(Equal(&dedicated))
+				return &serverscom.L7LoadBalancer{ID: "lb1", Status: "pending"}, nil
+			})
+
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("unknown cluster", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		clusterHandler.EXPECT().
+			GetLoadBalancerCluster(gomock.Any(), dedicated).
+			Return(nil, &serverscom.NotFoundError{Message: "Not found"})
+
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		g.Expect(err).To(MatchError(ContainSubstring("not found")))
+	})
+
+	t.Run("cluster in another location", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		clusterHandler.EXPECT().
+			GetLoadBalancerCluster(gomock.Any(), dedicated).
+			Return(&serverscom.LoadBalancerCluster{ID: dedicated, LocationID: 2}, nil)
+
+		_, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+		g.Expect(err).To(MatchError(ContainSubstring("location")))
+	})
+
+	t.Run("adopted lb on another cluster is refused", func(t *testing.T) {
+		g := NewWithT(t)
+		adopting := *gwInfo
+		adopting.AdoptLBID = "existing"
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "existing").
+			Return(&serverscom.L7LoadBalancer{ID: "existing", Name: "lb", Type: "l7", Status: config.LB_ACTIVE_STATUS, LocationID: 1}, nil)
+
+		_, err := manager.EnsureLB(context.Background(), &adopting, nil)
+		var immutableErr *utils.ImmutableChangeError
+		g.Expect(errors.As(err, &immutableErr)).To(BeTrue())
+		g.Expect(immutableErr.Current).To(Equal("shared"))
+		g.Expect(immutableErr.Desired).To(Equal(dedicated))
+	})
+
+	t.Run("cluster from env applies to created lb", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("SC_CLUSTER_ID", "env-cluster")
+		defaults := &types.GatewayInfo{UID: "gw-uid", VHosts: gwInfo.VHosts, Params: types.LBParams{LocationID: 1}}
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		clusterHandler.EXPECT().
+			GetLoadBalancerCluster(gomock.Any(), "env-cluster").
+			Return(&serverscom.LoadBalancerCluster{ID: "env-cluster", LocationID: 1}, nil)
+		lbHandler.EXPECT().
+			CreateL7LoadBalancer(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, in serverscom.L7LoadBalancerCreateInput) (*serverscom.L7LoadBalancer, error) {
+				g.Expect(*in.ClusterID).To(Equal("env-cluster"))
+				return &serverscom.L7LoadBalancer{ID: "lb1", Status: "pending"}, nil
+			})
+
+		_, err := manager.EnsureLB(context.Background(), defaults, nil)
+		g.Expect(err).To(BeNil())
+
+		lbInput, err := translateGatewayToLBInput(gwInfo, nil, config.DefaultKeys())
+		g.Expect(err).To(BeNil())
+		g.Expect(*lbInput.ClusterID).To(Equal(dedicated))
+	})
+
+	t.Run("existing lb keeps its cluster when env cluster changes", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("SC_CLUSTER_ID", "env-cluster")
+		defaults := &types.GatewayInfo{UID: "gw-uid", VHosts: gwInfo.VHosts, Params: types.LBParams{LocationID: 1}}
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{
+			{ID: "lb1", Status: config.LB_ACTIVE_STATUS, Labels: map[string]string{config.GW_LABEL_ID: "gw-uid"}},
+		}, nil)
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "lb1").
+			Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1, ClusterID: &dedicated}, nil)
+		lbHandler.EXPECT().
+			UpdateL7LoadBalancer(gomock.Any(), "lb1", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, in serverscom.L7LoadBalancerUpdateInput) (*serverscom.L7LoadBalancer, error) {
+				g.Expect(in.ClusterID).To(Equal(&dedicated))
+				return &serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS}, nil
+			})
+
+		_, err := manager.EnsureLB(context.Background(), defaults, nil)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("unchanged lb on dedicated cluster is not updated", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("SC_CLUSTER_ID", "env-cluster")
+		defaults := &types.GatewayInfo{UID: "gw-uid", VHosts: gwInfo.VHosts, Params: types.LBParams{LocationID: 1}}
+		lbInput, err := manager.buildLBInput(defaults, nil)
+		g.Expect(err).To(BeNil())
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{
+			{ID: "lb1", Status: config.LB_ACTIVE_STATUS, Labels: lbInput.Labels},
+		}, nil)
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "lb1").
+			Return(&serverscom.L7LoadBalancer{
+				ID:         "lb1",
+				Name:       lbInput.Name,
+				Status:     config.LB_ACTIVE_STATUS,
+				LocationID: 1,
+				ClusterID:  &dedicated,
+				Domains:    []string{"example.com"},
+				Labels:     lbInput.Labels,
+			}, nil)
+
+		res, err := manager.EnsureLB(context.Background(), defaults, nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(res.ID).To(Equal("lb1"))
+	})
+}
+
+func TestLBReplacement(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "", config.DefaultKeys(), time.Minute, 0, false)
+	now := time.Now()
+	manager.now = func() time.Time { return now }
+
+	gwInfo := &types.GatewayInfo{
+		UID: "gw-uid",
+		VHosts: map[string]*types.VHostInfo{
+			"example.com": {
+				Host:  "example.com",
+				Ports: []int32{80},
+				Paths: []types.PathInfo{{
+					Path:     "/",
+					Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+					NodeIps:  []string{"1.1.1.1"},
+				}},
+			},
+		},
+		Params: types.LBParams{LocationID: 2},
+	}
+	oldLB := serverscom.LoadBalancer{
+		ID:                "lb1",
+		Status:            config.LB_ACTIVE_STATUS,
+		LocationID:        1,
+		ExternalAddresses: []string{"10.0.0.1"},
+		Labels:            map[string]string{config.GW_LABEL_ID: "gw-uid"},
+	}
+	newLB := serverscom.LoadBalancer{
+		ID:                "lb2",
+		Status:            "pending",
+		LocationID:        2,
+		ExternalAddresses: []string{"10.0.0.2"},
+		Labels:            map[string]string{config.GW_LABEL_ID: "gw-uid", config.LB_REPLACES_LABEL: "lb1"},
+	}
+
+	// location changed, replacement is created
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB}, nil)
+	lbHandler.EXPECT().
+		GetL7LoadBalancer(gomock.Any(), "lb1").
+		Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1}, nil)
+	lbHandler.EXPECT().
+		CreateL7LoadBalancer(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_ context.Context, in serverscom.L7LoadBalancerCreateInput) (*serverscom.L7LoadBalancer, error) {
+			g.Expect(in.LocationID).To(Equal(int64(2)))
+			g.Expect(in.Labels).To(HaveKeyWithValue(config.LB_REPLACES_LABEL, "lb1"))
+			return &serverscom.L7LoadBalancer{ID: "lb2", Status: "pending"}, nil
+		})
+	lb, err := manager.EnsureLB(context.Background(), gwInfo, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(ReplacementInProgress(lb, config.DefaultKeys())).To(BeTrue())
+	g.Expect(lb.ExternalAddresses).To(Equal([]string{"10.0.0.1"}))
+
+	// replacement is not active yet, old lb keeps serving
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, newLB}, nil)
+	lb, err = manager.EnsureLB(context.Background(), gwInfo, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(ReplacementInProgress(lb, config.DefaultKeys())).To(BeTrue())
+	g.Expect(lb.ExternalAddresses).To(Equal([]string{"10.0.0.1"}))
+
+	// replacement is active, both addresses are published
+	newLB.Status = config.LB_ACTIVE_STATUS
+	lbInput, err := manager.buildLBInput(gwInfo, nil)
+	g.Expect(err).To(BeNil())
+	lbInput.Labels[config.LB_REPLACES_LABEL] = "lb1"
+	activeLB := &serverscom.L7LoadBalancer{
+		ID:                "lb2",
+		Name:              lbInput.Name,
+		Status:            config.LB_ACTIVE_STATUS,
+		LocationID:        2,
+		Domains:           []string{"example.com"},
+		ExternalAddresses: []string{"10.0.0.2"},
+		Labels:            lbInput.Labels,
+	}
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, newLB}, nil)
+	lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb2").Return(activeLB, nil)
+	lb, err = manager.EnsureLB(context.Background(), gwInfo, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(ReplacementInProgress(lb, config.DefaultKeys())).To(BeTrue())
+	g.Expect(lb.ExternalAddresses).To(Equal([]string{"10.0.0.2", "10.0.0.1"}))
+
+	// overlap is over, old lb is deleted
+	now = now.Add(2 * time.Minute)
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, newLB}, nil)
+	lbHandler.EXPECT().GetL7LoadBalancer(gomock.Any(), "lb2").Return(activeLB, nil)
+	lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb1").Return(nil)
+	lbHandler.EXPECT().
+		UpdateL7LoadBalancer(gomock.Any(), "lb2", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, in serverscom.L7LoadBalancerUpdateInput) (*serverscom.L7LoadBalancer, error) {
+			g.Expect(in.Labels).NotTo(HaveKey(config.LB_REPLACES_LABEL))
+			return &serverscom.L7LoadBalancer{ID: "lb2", Status: config.LB_ACTIVE_STATUS, ExternalAddresses: []string{"10.0.0.2"}, Labels: in.Labels}, nil
+		})
+	lb, err = manager.EnsureLB(context.Background(), gwInfo, nil)
+	g.Expect(err).To(BeNil())
+	g.Expect(ReplacementInProgress(lb, config.DefaultKeys())).To(BeFalse())
+	g.Expect(lb.ExternalAddresses).To(Equal([]string{"10.0.0.2"}))
+	g.Expect(manager.activeSince).To(BeEmpty())
+
+	// gateway deleted during replacement, both lbs are deleted
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, newLB}, nil)
+	lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb2").Return(nil)
+	lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb1").Return(nil)
+	g.Expect(manager.DeleteLB(context.Background(), "gw-uid")).To(Succeed())
+}
+
+func TestLBReplacementCancel(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	lbHandler := mocks.NewMockLoadBalancersService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.LoadBalancer](mockCtrl)
+	lbHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.LoadBalancers = lbHandler
+	manager := NewManager(client, "", config.DefaultKeys(), time.Minute, 30*time.Minute, false)
+	now := time.Now()
+	manager.now = func() time.Time { return now }
+
+	gwInfo := func(location int64) *types.GatewayInfo {
+		return &types.GatewayInfo{
+			UID: "gw-uid",
+			VHosts: map[string]*types.VHostInfo{
+				"example.com": {
+					Host:  "example.com",
+					Ports: []int32{80},
+					Paths: []types.PathInfo{{
+						Path:     "/",
+						Backends: []types.BackendInfo{{Service: &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "svc"}}, NodePort: 8080, Weight: 1}},
+						NodeIps:  []string{"1.1.1.1"},
+					}},
+				},
+			},
+			Params: types.LBParams{LocationID: location},
+		}
+	}
+	oldLB := serverscom.LoadBalancer{
+		ID:         "lb1",
+		Name:       "gw-lb",
+		Status:     config.LB_ACTIVE_STATUS,
+		LocationID: 1,
+		Labels:     map[string]string{config.GW_LABEL_ID: "gw-uid"},
+	}
+	newLB := serverscom.LoadBalancer{
+		ID:         "lb2",
+		Status:     "pending",
+		LocationID: 2,
+		Labels:     map[string]string{config.GW_LABEL_ID: "gw-uid", config.LB_REPLACES_LABEL: "lb1"},
+		Created:    now,
+	}
+
+	t.Run("reverted settings cancel replacement", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, newLB}, nil)
+		lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb2").Return(nil)
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "lb1").
+			Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1}, nil)
+		lbHandler.EXPECT().
+			UpdateL7LoadBalancer(gomock.Any(), "lb1", gomock.Any()).
+			Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1}, nil)
+
+		lb, err := manager.EnsureLB(context.Background(), gwInfo(1), nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(ReplacementInProgress(lb, config.DefaultKeys())).To(BeFalse())
+		g.Expect(lb.ID).To(Equal("lb1"))
+	})
+
+	t.Run("replacement not active in time is cancelled", func(t *testing.T) {
+		g := NewWithT(t)
+		now = now.Add(time.Hour)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{oldLB, newLB}, nil)
+		lbHandler.EXPECT().
+			UpdateL7LoadBalancer(gomock.Any(), "lb1", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, in serverscom.L7LoadBalancerUpdateInput) (*serverscom.L7LoadBalancer, error) {
+				g.Expect(in.Labels).To(HaveKeyWithValue(config.LB_REPLACE_FAILED_LABEL, "2-shared"))
+				g.Expect(in.Labels).To(HaveKeyWithValue(config.GW_LABEL_ID, "gw-uid"))
+				g.Expect(in.UpstreamZones).To(BeEmpty())
+				return &serverscom.L7LoadBalancer{ID: "lb1"}, nil
+			})
+		lbHandler.EXPECT().DeleteL7LoadBalancer(gomock.Any(), "lb2").Return(nil)
+
+		_, err := manager.EnsureLB(context.Background(), gwInfo(2), nil)
+		var timeoutErr *utils.ReplacementTimeoutError
+		g.Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+	})
+
+	t.Run("timed out replacement is not retried", func(t *testing.T) {
+		g := NewWithT(t)
+		failed := oldLB
+		failed.Labels = map[string]string{config.GW_LABEL_ID: "gw-uid", config.LB_REPLACE_FAILED_LABEL: "2-shared"}
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{failed}, nil)
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "lb1").
+			Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1, Labels: failed.Labels}, nil)
+
+		_, err := manager.EnsureLB(context.Background(), gwInfo(2), nil)
+		var timeoutErr *utils.ReplacementTimeoutError
+		g.Expect(errors.As(err, &timeoutErr)).To(BeTrue())
+
+		// another location is replaced again
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.LoadBalancer{failed}, nil)
+		lbHandler.EXPECT().
+			GetL7LoadBalancer(gomock.Any(), "lb1").
+			Return(&serverscom.L7LoadBalancer{ID: "lb1", Status: config.LB_ACTIVE_STATUS, LocationID: 1, Labels: failed.Labels}, nil)
+		lbHandler.EXPECT().
+			CreateL7LoadBalancer(gomock.Any(), gomock.Any()).
+			Return(&serverscom.L7LoadBalancer{ID: "lb3", Status: "pending"}, nil)
+
+		lb, err := manager.EnsureLB(context.Background(), gwInfo(3), nil)
+		g.Expect(err).To(BeNil())
+		g.Expect(ReplacementInProgress(lb, config.DefaultKeys())).To(BeTrue())
+	})
+}
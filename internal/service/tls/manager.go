@@ -17,14 +17,20 @@ import (
 
 type TLSManagerInterface interface {
 	EnsureTLS(ctx context.Context, tlsInfo map[string]types.TLSConfigInfo) (map[string]string, error)
+	ListManagedCertificates(ctx context.Context) ([]serverscom.SSLCertificate, error)
+	DeleteCertificate(ctx context.Context, id string) error
 }
 
 type Manager struct {
-	scCli *serverscom.Client
+	scCli     *serverscom.Client
+	clusterID string
+	keys      config.Keys
 }
 
-func NewManager(c *serverscom.Client) *Manager {
-	return &Manager{scCli: c}
+// NewManager creates TLS manager, clusterID is stamped on created certificates to distinguish clusters sharing one account.
+// keys.SecretLabel marks certificates managed by this controller instance.
+func NewManager(c *serverscom.Client, clusterID string, keys config.Keys) *Manager {
+	return &Manager{scCli: c, clusterID: clusterID, keys: keys}
 }
 
 // EnsureTLS ensures all TLS certificates exist in the provider.
@@ -68,6 +74,29 @@ func (m *Manager) EnsureTLS(ctx context.Context, tlsInfo map[string]types.TLSCon
 	return res, nil
 }
 
+// ListManagedCertificates returns all custom certificates created by controller of this cluster for secrets.
+func (m *Manager) ListManagedCertificates(ctx context.Context) ([]serverscom.SSLCertificate, error) {
+	certs, err := m.scCli.SSLCertificates.Collection().
+		SetParam("label_selector", m.keys.SecretLabel).
+		SetParam("type", "custom").
+		Collect(ctx)
+	if err != nil {
+		return nil, utils.IgnoreNotFound(err)
+	}
+	var owned []serverscom.SSLCertificate
+	for _, c := range certs {
+		if utils.BelongsToCluster(c.Labels, m.keys, m.clusterID) {
+			owned = append(owned, c)
+		}
+	}
+	return owned, nil
+}
+
+// DeleteCertificate deletes custom certificate by its ID.
+func (m *Manager) DeleteCertificate(ctx context.Context, id string) error {
+	return utils.IgnoreNotFound(m.scCli.SSLCertificates.DeleteCustom(ctx, id))
+}
+
 // getByID gets cert by external ID
 func (m *Manager) getByID(id string) (*serverscom.SSLCertificate, error) {
 	customCert, err := m.scCli.SSLCertificates.GetCustom(context.Background(), id)
@@ -89,26 +118,39 @@ func (m *Manager) ensureCertificateForSecret(
 	if err != nil {
 		return nil, err
 	}
-	if foundCrt != nil && foundCrt.Sha1Fingerprint == fingerprint {
+	if foundCrt != nil && foundCrt.Sha1Fingerprint == fingerprint && !m.needsRelabel(foundCrt, secretUID) {
 		return foundCrt, nil
 	}
+	// update also stamps current labels on certificates created before them
 	if foundCrt != nil && foundCrt.ID != "" {
-		return m.updateCertificateForSecret(ctx, foundCrt.ID, cert, key, chain)
+		return m.updateCertificateForSecret(ctx, foundCrt.ID, secretUID, cert, key, chain)
 	}
 	return m.createCertificateForSecret(ctx, secretUID, cert, key, chain)
 }
 
 // findCertificate searches for a certificate in provider by secret label.
+// If old secret label key configured and nothing found by current key, certificate is searched by old key.
 // fingerprint is used to match same cert.
+// Returns ClusterConflictError if certificate for the secret belongs to another cluster.
 func (m *Manager) findCertificate(ctx context.Context, fingerprint, secretUID string) (*serverscom.SSLCertificate, error) {
-	labelSelector := config.SECRET_LABEL_ID + "=" + secretUID
-	certs, err := m.scCli.SSLCertificates.Collection().
-		SetParam("label_selector", labelSelector).
-		SetParam("type", "custom").
-		Collect(ctx)
+	found, err := m.getCertificatesByLabel(ctx, m.keys.SecretLabel+"="+secretUID)
+	if err == nil && len(found) == 0 && m.keys.OldSecretLabel != "" {
+		found, err = m.getCertificatesByLabel(ctx, m.keys.OldSecretLabel+"="+secretUID)
+	}
 	if err != nil {
 		return nil, utils.IgnoreNotFound(err)
 	}
+	var certs []serverscom.SSLCertificate
+	for _, c := range found {
+		if !utils.BelongsToCluster(c.Labels, m.keys, m.clusterID) {
+			return nil, &utils.ClusterConflictError{
+				Kind:      "certificate",
+				Name:      c.Name,
+				ClusterID: utils.ClusterOwner(c.Labels, m.keys),
+			}
+		}
+		certs = append(certs, c)
+	}
 	for _, c := range certs {
 		if c.Sha1Fingerprint == fingerprint {
 			return &c, nil
@@ -121,11 +163,25 @@ func (m *Manager) findCertificate(ctx context.Context, fingerprint, secretUID st
 	return nil, nil
 }
 
+// getCertificatesByLabel retrieves custom certificates from provider filtered by label selector.
+func (m *Manager) getCertificatesByLabel(ctx context.Context, labelSelector string) ([]serverscom.SSLCertificate, error) {
+	return m.scCli.SSLCertificates.Collection().
+		SetParam("label_selector", labelSelector).
+		SetParam("type", "custom").
+		Collect(ctx)
+}
+
+// needsRelabel returns true if certificate has no current secret or cluster label.
+func (m *Manager) needsRelabel(cert *serverscom.SSLCertificate, secretUID string) bool {
+	return cert.Labels[m.keys.SecretLabel] != secretUID || !utils.OwnedByCluster(cert.Labels, m.keys, m.clusterID)
+}
+
 // updateCertificateForSecret updates certificate in provider.
-func (m *Manager) updateCertificateForSecret(ctx context.Context, id string, cert, key, chain []byte) (*serverscom.SSLCertificate, error) {
+func (m *Manager) updateCertificateForSecret(ctx context.Context, id, secretUID string, cert, key, chain []byte) (*serverscom.SSLCertificate, error) {
 	in := serverscom.SSLCertificateUpdateCustomInput{
 		PublicKey:  string(cert),
 		PrivateKey: string(key),
+		Labels:     m.certificateLabels(secretUID),
 	}
 	if len(chain) > 0 {
 		in.ChainKey = string(chain)
@@ -143,9 +199,7 @@ func (m *Manager) createCertificateForSecret(ctx context.Context, secretUID stri
 		Name:       "gw-secret-" + secretUID,
 		PublicKey:  string(cert),
 		PrivateKey: string(key),
-		Labels: map[string]string{
-			config.SECRET_LABEL_ID: secretUID,
-		},
+		Labels:     m.certificateLabels(secretUID),
 	}
 	if len(chain) > 0 {
 		in.ChainKey = string(chain)
@@ -156,3 +210,14 @@ func (m *Manager) createCertificateForSecret(ctx context.Context, secretUID stri
 	}
 	return customToSSLCertificate(out), nil
 }
+
+// certificateLabels returns labels of certificate created for secret
+func (m *Manager) certificateLabels(secretUID string) map[string]string {
+	labels := map[string]string{
+		m.keys.SecretLabel: secretUID,
+	}
+	if m.clusterID != "" {
+		labels[m.keys.ClusterLabel()] = m.clusterID
+	}
+	return labels
+}
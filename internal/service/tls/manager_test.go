@@ -16,9 +16,12 @@ import (
 
 	serverscom "github.com/serverscom/serverscom-go-client/pkg"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/serverscom/api-gateway-controller/internal/config"
 	"github.com/serverscom/api-gateway-controller/internal/mocks"
 	"github.com/serverscom/api-gateway-controller/internal/types"
+	"github.com/serverscom/api-gateway-controller/internal/utils"
 
 	"go.uber.org/mock/gomock"
 )
@@ -41,7 +44,7 @@ func TestEnsureTLS(t *testing.T) {
 
 	client := serverscom.NewClientWithEndpoint("", "")
 	client.SSLCertificates = sslHandler
-	manager := NewManager(client)
+	manager := NewManager(client, "", config.DefaultKeys())
 
 	certPEM, keyPEM := generateCertAndKey(t)
 	secret := &corev1.Secret{
@@ -129,6 +132,170 @@ func TestEnsureTLS(t *testing.T) {
 	}
 }
 
+func TestEnsureTLSClusterOwnership(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	sslHandler := mocks.NewMockSSLCertificatesService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.SSLCertificate](mockCtrl)
+	sslHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam(gomock.Any(), gomock.Any()).Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.SSLCertificates = sslHandler
+	manager := NewManager(client, "cluster-a", config.DefaultKeys())
+
+	certPEM, keyPEM := generateCertAndKey(t)
+	primary, _ := splitCerts(certPEM)
+	fp := getPemFingerprint(primary)
+	tlsInfo := map[string]types.TLSConfigInfo{
+		"example.com": {Secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{UID: "secret-uid"},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       certPEM,
+				corev1.TLSPrivateKeyKey: keyPEM,
+			},
+		}},
+	}
+
+	t.Run("create stamps cluster label", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+		sslHandler.EXPECT().
+			CreateCustom(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, in serverscom.SSLCertificateCreateCustomInput) (*serverscom.SSLCertificateCustom, error) {
+				g.Expect(in.Labels).To(HaveKeyWithValue(config.CLUSTER_LABEL_ID, "cluster-a"))
+				g.Expect(in.Labels).To(HaveKeyWithValue(config.SECRET_LABEL_ID, "secret-uid"))
+				return &serverscom.SSLCertificateCustom{ID: "new"}, nil
+			})
+		_, err := manager.EnsureTLS(context.Background(), tlsInfo)
+		g.Expect(err).To(BeNil())
+	})
+
+	t.Run("legacy certificate is relabelled", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.SSLCertificate{
+			{ID: "legacy", Sha1Fingerprint: fp, Labels: map[string]string{config.SECRET_LABEL_ID: "secret-uid"}},
+		}, nil)
+		sslHandler.EXPECT().
+			UpdateCustom(gomock.Any(), "legacy", gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ string, in serverscom.SSLCertificateUpdateCustomInput) (*serverscom.SSLCertificateCustom, error) {
+				g.Expect(in.Labels).To(HaveKeyWithValue(config.CLUSTER_LABEL_ID, "cluster-a"))
+				return &serverscom.SSLCertificateCustom{ID: "legacy"}, nil
+			})
+		res, err := manager.EnsureTLS(context.Background(), tlsInfo)
+		g.Expect(err).To(BeNil())
+		g.Expect(res).To(Equal(map[string]string{"example.com": "legacy"}))
+	})
+
+	t.Run("certificate of another cluster is conflict", func(t *testing.T) {
+		g := NewWithT(t)
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.SSLCertificate{
+			{ID: "foreign", Sha1Fingerprint: fp, Labels: map[string]string{config.SECRET_LABEL_ID: "secret-uid", config.CLUSTER_LABEL_ID: "cluster-b"}},
+		}, nil)
+		_, err := manager.EnsureTLS(context.Background(), tlsInfo)
+		var conflictErr *utils.ClusterConflictError
+		g.Expect(errors.As(err, &conflictErr)).To(BeTrue())
+	})
+
+	t.Run("list returns own and legacy certificates", func(t *testing.T) {
+		g := NewWithT(t)
+		own := serverscom.SSLCertificate{ID: "own", Labels: map[string]string{config.CLUSTER_LABEL_ID: "cluster-a"}}
+		legacy := serverscom.SSLCertificate{ID: "legacy"}
+		foreign := serverscom.SSLCertificate{ID: "foreign", Labels: map[string]string{config.CLUSTER_LABEL_ID: "cluster-b"}}
+		collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.SSLCertificate{own, legacy, foreign}, nil)
+		certs, err := manager.ListManagedCertificates(context.Background())
+		g.Expect(err).To(BeNil())
+		g.Expect(certs).To(Equal([]serverscom.SSLCertificate{own, legacy}))
+	})
+}
+
+func TestEnsureTLSLabelKeysMigration(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	sslHandler := mocks.NewMockSSLCertificatesService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.SSLCertificate](mockCtrl)
+	sslHandler.EXPECT().Collection().Return(collectionHandler).AnyTimes()
+	collectionHandler.EXPECT().SetParam("type", "custom").Return(collectionHandler).AnyTimes()
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.SSLCertificates = sslHandler
+	keys := config.Keys{SecretLabel: "staging/secret-id", OldSecretLabel: config.SECRET_LABEL_ID}
+	manager := NewManager(client, "", keys)
+
+	certPEM, keyPEM := generateCertAndKey(t)
+	primary, _ := splitCerts(certPEM)
+	tlsInfo := map[string]types.TLSConfigInfo{
+		"example.com": {Secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{UID: "secret-uid"},
+			Data: map[string][]byte{
+				corev1.TLSCertKey:       certPEM,
+				corev1.TLSPrivateKeyKey: keyPEM,
+			},
+		}},
+	}
+
+	collectionHandler.EXPECT().SetParam("label_selector", "staging/secret-id=secret-uid").Return(collectionHandler)
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return(nil, nil)
+	collectionHandler.EXPECT().SetParam("label_selector", config.SECRET_LABEL_ID+"=secret-uid").Return(collectionHandler)
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.SSLCertificate{
+		{ID: "old", Sha1Fingerprint: getPemFingerprint(primary), Labels: map[string]string{config.SECRET_LABEL_ID: "secret-uid"}},
+	}, nil)
+	sslHandler.EXPECT().
+		UpdateCustom(gomock.Any(), "old", gomock.Any()).
+		DoAndReturn(func(_ context.Context, _ string, in serverscom.SSLCertificateUpdateCustomInput) (*serverscom.SSLCertificateCustom, error) {
+			g.Expect(in.Labels).To(Equal(map[string]string{"staging/secret-id": "secret-uid"}))
+			return &serverscom.SSLCertificateCustom{ID: "old"}, nil
+		})
+
+	res, err := manager.EnsureTLS(context.Background(), tlsInfo)
+	g.Expect(err).To(BeNil())
+	g.Expect(res).To(Equal(map[string]string{"example.com": "old"}))
+}
+
+func TestListManagedCertificates(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	sslHandler := mocks.NewMockSSLCertificatesService(mockCtrl)
+	collectionHandler := mocks.NewMockCollection[serverscom.SSLCertificate](mockCtrl)
+
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.SSLCertificates = sslHandler
+	manager := NewManager(client, "", config.DefaultKeys())
+
+	sslHandler.EXPECT().Collection().Return(collectionHandler)
+	collectionHandler.EXPECT().SetParam("label_selector", config.SECRET_LABEL_ID).Return(collectionHandler)
+	collectionHandler.EXPECT().SetParam("type", "custom").Return(collectionHandler)
+	collectionHandler.EXPECT().Collect(gomock.Any()).Return([]serverscom.SSLCertificate{{ID: "cert1"}}, nil)
+
+	certs, err := manager.ListManagedCertificates(context.Background())
+	g.Expect(err).To(BeNil())
+	g.Expect(certs).To(HaveLen(1))
+}
+
+func TestDeleteCertificate(t *testing.T) {
+	g := NewWithT(t)
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	sslHandler := mocks.NewMockSSLCertificatesService(mockCtrl)
+	client := serverscom.NewClientWithEndpoint("", "")
+	client.SSLCertificates = sslHandler
+	manager := NewManager(client, "", config.DefaultKeys())
+
+	sslHandler.EXPECT().DeleteCustom(gomock.Any(), "cert1").Return(nil)
+	sslHandler.EXPECT().DeleteCustom(gomock.Any(), "cert2").Return(&serverscom.NotFoundError{Message: "Not found"})
+	sslHandler.EXPECT().DeleteCustom(gomock.Any(), "cert3").Return(errors.New("api error"))
+
+	g.Expect(manager.DeleteCertificate(context.Background(), "cert1")).To(BeNil())
+	g.Expect(manager.DeleteCertificate(context.Background(), "cert2")).To(BeNil())
+	g.Expect(manager.DeleteCertificate(context.Background(), "cert3")).To(HaveOccurred())
+}
+
 func generateCertAndKey(t *testing.T) ([]byte, []byte) {
 	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	if err != nil {
@@ -1,23 +1,80 @@
 package types
 
 import (
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/api/v1alpha1"
+
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
 
 // GatewayInfo represents gateway info.
 // Gathering in Reconcile loop contains info to build input for our load balancer.
 type GatewayInfo struct {
-	UID    string
-	Name   string
-	NS     string
-	VHosts map[string]*VHostInfo
+	UID       string
+	Name      string
+	NS        string
+	VHosts    map[string]*VHostInfo
+	Routes    map[string]*RouteInfo
+	Listeners map[string]*ListenerStatusInfo
+	// AdoptLBID is ID of existing LB to take under management instead of creating new one
+	AdoptLBID string
+	// Params contains LB settings from parameters of gateway class and gateway infrastructure
+	Params LBParams
+	// Labels contains gateway infrastructure labels copied onto LB
+	Labels map[string]string
+	// NodeDrains contains time drain ends by name of draining node
+	NodeDrains map[string]time.Time
+	// DrainedNodes contains names of nodes removed from upstreams after drain
+	DrainedNodes []string
+}
+
+// LBParams represents load balancer settings configured outside of gateway spec.
+// Unset fields are left to controller defaults.
+type LBParams struct {
+	LocationID        int64
+	ClusterID         *string
+	StoreLogs         *bool
+	StoreLogsRegionID *int
+	Geoip             *bool
+	// UpstreamMode is default upstream mode of gateway backends
+	UpstreamMode v1alpha1.UpstreamMode
 }
 
 type PathInfo struct {
-	Path     string
+	Path string
+	// PathType is a type of path match, empty means PathPrefix
+	PathType gatewayv1.PathMatchType
+	Backends []BackendInfo
+	NodeIps  []string
+	// DrainingNodeIps are IPs of cordoned or deleted nodes, until drain ends they get minimal weight
+	// and a single connection, so in-flight requests finish while new ones mostly go to active nodes
+	DrainingNodeIps []string
+}
+
+// BackendInfo represents Service which receives traffic for path.
+// Traffic is split between backends proportionally to Weight.
+type BackendInfo struct {
 	Service  *corev1.Service
 	NodePort int
-	NodeIps  []string
+	Weight   int32
+	// LocalEndpoints contains number of ready endpoints by node IP for Service with Local external traffic policy,
+	// only these nodes receive traffic. Nil means traffic is sent to all nodes.
+	LocalEndpoints map[string]int32
+	// Direct is set in Pod upstream mode, traffic is sent to PodEndpoints instead of NodePort of nodes
+	Direct bool
+	// ServicePort is Service port of backend, it identifies backend in Pod upstream mode
+	ServicePort int32
+	// PodEndpoints contains ready pod endpoints of backend in Pod upstream mode
+	PodEndpoints []PodEndpoint
+}
+
+// PodEndpoint represents pod IP and target port receiving traffic directly from LB
+type PodEndpoint struct {
+	IP   string
+	Port int32
 }
 
 type VHostInfo struct {
@@ -44,3 +101,24 @@ type ListenerInfo struct {
 	AllowedFrom string
 	Selector    map[string]string
 }
+
+// ListenerStatusInfo represents listener status info.
+// Gathering in Reconcile loop contains data for Gateway listeners status except Programmed condition.
+type ListenerStatusInfo struct {
+	SupportedKinds []gatewayv1.RouteGroupKind
+	AttachedRoutes int32
+	Conditions     []metav1.Condition
+}
+
+// RouteInfo represents HTTPRoute info.
+// Gathering in Reconcile loop contains status of the route for each parentRef pointing to the Gateway.
+type RouteInfo struct {
+	Route   *gatewayv1.HTTPRoute
+	Parents []RouteParentInfo
+}
+
+// RouteParentInfo represents route conditions for a single parentRef.
+type RouteParentInfo struct {
+	ParentRef  gatewayv1.ParentReference
+	Conditions []metav1.Condition
+}
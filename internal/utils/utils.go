@@ -2,10 +2,49 @@ package utils
 
 import (
 	"errors"
+	"fmt"
+	"time"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
 
 	serverscom "github.com/serverscom/serverscom-go-client/pkg"
 )
 
+// ClusterConflictError is returned when provider resource matched by labels belongs to another cluster
+type ClusterConflictError struct {
+	Kind      string
+	Name      string
+	ClusterID string
+}
+
+func (e *ClusterConflictError) Error() string {
+	return fmt.Sprintf("%s %q belongs to another cluster %q", e.Kind, e.Name, e.ClusterID)
+}
+
+// ImmutableChangeError is returned when desired load balancer differs from existing one in field which can't be updated
+type ImmutableChangeError struct {
+	Name    string
+	Field   string
+	Current string
+	Desired string
+}
+
+func (e *ImmutableChangeError) Error() string {
+	return fmt.Sprintf("load balancer %q %s can't be changed from %q to %q", e.Name, e.Field, e.Current, e.Desired)
+}
+
+// ReplacementTimeoutError is returned when replacement load balancer didn't become active in time and was cancelled
+type ReplacementTimeoutError struct {
+	Name    string
+	Target  string
+	Timeout time.Duration
+}
+
+func (e *ReplacementTimeoutError) Error() string {
+	return fmt.Sprintf("replacement of load balancer %q on %s didn't become active in %s and was cancelled, change location or cluster to retry",
+		e.Name, e.Target, e.Timeout)
+}
+
 func BoolPtr(v bool) *bool {
 	return &v
 }
@@ -17,3 +56,33 @@ func IgnoreNotFound(err error) error {
 	}
 	return err
 }
+
+func IgnoreClusterConflict(err error) error {
+	var conflictErr *ClusterConflictError
+	if errors.As(err, &conflictErr) {
+		return nil
+	}
+	return err
+}
+
+// BelongsToCluster returns true if resource labels has no cluster label or it equals to clusterID.
+// Resources without cluster label were created before cluster identity was introduced.
+// Cluster label of keys to migrate from is checked too, resources found by old keys are labelled with it.
+func BelongsToCluster(labels map[string]string, keys config.Keys, clusterID string) bool {
+	owner := ClusterOwner(labels, keys)
+	return owner == "" || owner == clusterID
+}
+
+// OwnedByCluster returns true if resource labels has cluster label equal to clusterID.
+func OwnedByCluster(labels map[string]string, keys config.Keys, clusterID string) bool {
+	return labels[keys.ClusterLabel()] == clusterID
+}
+
+// ClusterOwner returns cluster identity from resource labels, cluster label of keys to migrate from is used
+// if resource has no current one.
+func ClusterOwner(labels map[string]string, keys config.Keys) string {
+	if owner, ok := labels[keys.ClusterLabel()]; ok || keys.OldGatewayLabel == "" {
+		return owner
+	}
+	return labels[keys.Old().ClusterLabel()]
+}
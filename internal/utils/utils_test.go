@@ -0,0 +1,49 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/serverscom/api-gateway-controller/internal/config"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestClusterOwnership(t *testing.T) {
+	g := NewWithT(t)
+
+	own := map[string]string{config.CLUSTER_LABEL_ID: "a"}
+	foreign := map[string]string{config.CLUSTER_LABEL_ID: "b"}
+	legacy := map[string]string{}
+
+	keys := config.DefaultKeys()
+
+	g.Expect(BelongsToCluster(own, keys, "a")).To(BeTrue())
+	g.Expect(BelongsToCluster(legacy, keys, "a")).To(BeTrue())
+	g.Expect(BelongsToCluster(foreign, keys, "a")).To(BeFalse())
+
+	g.Expect(OwnedByCluster(own, keys, "a")).To(BeTrue())
+	g.Expect(OwnedByCluster(legacy, keys, "a")).To(BeFalse())
+	g.Expect(OwnedByCluster(foreign, keys, "a")).To(BeFalse())
+
+	// cluster label of other instance keys isn't taken into account
+	custom := config.Keys{GatewayLabel: "staging.example.com/gateway-id"}
+	g.Expect(BelongsToCluster(foreign, custom, "a")).To(BeTrue())
+	g.Expect(OwnedByCluster(own, custom, "a")).To(BeFalse())
+
+	// resources found by old keys are checked by old cluster label
+	migrating := config.Keys{GatewayLabel: "staging.example.com/gateway-id", OldGatewayLabel: config.GW_LABEL_ID}
+	g.Expect(BelongsToCluster(foreign, migrating, "a")).To(BeFalse())
+	g.Expect(ClusterOwner(foreign, migrating)).To(Equal("b"))
+	g.Expect(BelongsToCluster(map[string]string{migrating.ClusterLabel(): "a", config.CLUSTER_LABEL_ID: "b"}, migrating, "a")).To(BeTrue())
+}
+
+func TestIgnoreClusterConflict(t *testing.T) {
+	g := NewWithT(t)
+
+	conflict := fmt.Errorf("wrapped: %w", &ClusterConflictError{Kind: "load balancer", Name: "lb", ClusterID: "b"})
+	g.Expect(IgnoreClusterConflict(conflict)).To(BeNil())
+	g.Expect(IgnoreClusterConflict(errors.New("other"))).To(HaveOccurred())
+	g.Expect(conflict.Error()).To(ContainSubstring(`load balancer "lb" belongs to another cluster "b"`))
+}